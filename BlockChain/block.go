@@ -1,9 +1,7 @@
 package BlockChain
 
 import (
-	"bytes"
-	"crypto/sha256"
-	"encoding/gob"
+	"math/big"
 	"time"
 
 	"CHAIN/common" // 根据你的项目路径导入 common 包
@@ -15,6 +13,12 @@ type Block struct {
 	PrevHash     []byte
 	Hash         []byte
 	Nonce        uint64
+	StateRoot    common.Hash // 执行完本区块交易后的状态根
+	Difficulty   uint64
+	GasLimit     uint64
+	GasUsed      uint64                // 本区块交易实际消耗的 Gas
+	BaseFee      *big.Int              // EIP-1559 基础费用，London 之前为 nil
+	Coinbase     common.Address        // 出块者地址，接收交易小费
 	Transactions []*common.Transaction // 修改这里
 }
 
@@ -30,15 +34,7 @@ func NewBlock(transactions []*common.Transaction, prevHash []byte, index uint64)
 	return block
 }
 
-// CalculateHash 计算区块哈希
+// CalculateHash 计算区块哈希，即区块头规范编码的 keccak256 哈希
 func (b *Block) CalculateHash() []byte {
-	var buf bytes.Buffer
-	enc := gob.NewEncoder(&buf)
-	_ = enc.Encode(b.Index)
-	_ = enc.Encode(b.Timestamp)
-	_ = enc.Encode(b.PrevHash)
-	_ = enc.Encode(b.Transactions) // 这里自动序列化 []*common.Transaction
-	_ = enc.Encode(b.Nonce)
-	hash := sha256.Sum256(buf.Bytes())
-	return hash[:]
+	return b.Header().Hash()
 }
@@ -0,0 +1,152 @@
+package BlockChain
+
+import (
+	"CHAIN/common"
+	"CHAIN/kvstore"
+	"CHAIN/params"
+	"CHAIN/statedb"
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrUnknownParent 表示区块的父区块尚未导入，或区块高度与父区块不连续
+	ErrUnknownParent = errors.New("unknown parent block")
+	// ErrInvalidTransaction 表示区块中包含无法执行的交易
+	ErrInvalidTransaction = errors.New("invalid transaction in block")
+	// ErrStateRootMismatch 表示执行区块后的状态根与区块头不一致
+	ErrStateRootMismatch = errors.New("state root mismatch")
+	// ErrKnownBlock 表示区块已经导入过
+	ErrKnownBlock = errors.New("block already known")
+)
+
+// Chain 维护从创世区块开始的规范链，并负责导入新区块。
+// 父区块已知的区块都可以导入，不在规范链上的区块作为侧链保存
+type Chain struct {
+	db     kvstore.KVStore
+	config *params.ChainConfig
+	blocks []*Block          // 规范链，blocks[i] 的高度为创世区块高度加 i
+	known  map[string]*Block // 已导入的全部区块（规范链与侧链），按哈希索引
+	lock   sync.RWMutex
+}
+
+// NewChain 以已提交的创世区块创建链
+func NewChain(db kvstore.KVStore, config *params.ChainConfig, genesis *Block) *Chain {
+	return &Chain{
+		db:     db,
+		config: config,
+		blocks: []*Block{genesis},
+		known:  map[string]*Block{string(genesis.Hash): genesis},
+	}
+}
+
+// Config 返回链配置
+func (c *Chain) Config() *params.ChainConfig {
+	return c.config
+}
+
+// CurrentBlock 返回当前链头
+func (c *Chain) CurrentBlock() *Block {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.blocks[len(c.blocks)-1]
+}
+
+// Len 返回规范链上的区块数量（包含创世区块）
+func (c *Chain) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return len(c.blocks)
+}
+
+// GetBlock 返回哈希为 hash 的区块（规范链或侧链），不存在时返回 nil。
+// 重组后被丢弃分支上的区块仍可读取，交易池据此重新加入其中的交易
+func (c *Chain) GetBlock(hash []byte) *Block {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.known[string(hash)]
+}
+
+// State 返回当前链头的状态
+func (c *Chain) State() (*statedb.MPTStateDB, error) {
+	return statedb.New(c.CurrentBlock().StateRoot, c.db)
+}
+
+// InsertBlock 校验并导入一个父区块已知的区块，父区块可以在侧链上：
+// 校验基础费用后在父区块的状态上逐笔执行交易，签名无效或无法执行的交易使整个区块被拒绝，
+// 执行结果的 Gas 用量和状态根必须与区块头一致。
+// 高度超过当前链头的区块成为新链头，规范链切换到它所在的分支（最长链规则）；
+// 其余区块作为侧链保存。
+func (c *Chain) InsertBlock(block *Block) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.known[string(block.Hash)] != nil {
+		return fmt.Errorf("%w: block %d %x", ErrKnownBlock, block.Index, block.Hash)
+	}
+	parent := c.known[string(block.PrevHash)]
+	if parent == nil || block.Index != parent.Index+1 {
+		return fmt.Errorf("%w: block %d prev %x", ErrUnknownParent, block.Index, block.PrevHash)
+	}
+
+	if err := verifyBaseFee(c.config, parent, block); err != nil {
+		return err
+	}
+
+	state, err := statedb.New(parent.StateRoot, c.db)
+	if err != nil {
+		return err
+	}
+	signer := common.MakeSigner(c.config, block.Index)
+	var gasUsed uint64
+	for i, tx := range block.Transactions {
+		if tx.GasLimit > block.GasLimit-gasUsed {
+			return fmt.Errorf("%w: tx %d gas %d, remaining %d", ErrGasLimitReached, i, tx.GasLimit, block.GasLimit-gasUsed)
+		}
+		gas, err := ApplyTransaction(state, signer, block, tx)
+		if err != nil {
+			return fmt.Errorf("%w: tx %d: %w", ErrInvalidTransaction, i, err)
+		}
+		gasUsed += gas
+	}
+	if gasUsed != block.GasUsed {
+		return fmt.Errorf("%w: have %d, header %d", ErrGasUsedMismatch, gasUsed, block.GasUsed)
+	}
+	root, err := state.Commit()
+	if err != nil {
+		return err
+	}
+	if root != block.StateRoot {
+		return fmt.Errorf("%w: have %x, header %x", ErrStateRootMismatch, root, block.StateRoot)
+	}
+
+	c.known[string(block.Hash)] = block
+	if head := c.blocks[len(c.blocks)-1]; block.Index > head.Index {
+		c.setHead(block)
+	}
+	return nil
+}
+
+// setHead 把 head 所在的分支设为规范链：从 head 回溯到规范链上的共同祖先，
+// 用回溯经过的区块替换规范链上祖先之后的区块。调用方需持有 c.lock
+func (c *Chain) setHead(head *Block) {
+	var (
+		base   = c.blocks[0].Index
+		branch []*Block
+		block  = head
+	)
+	// 创世区块总在规范链上，回溯一定会停止
+	for {
+		if i := block.Index - base; i < uint64(len(c.blocks)) && bytes.Equal(c.blocks[i].Hash, block.Hash) {
+			break
+		}
+		branch = append(branch, block)
+		block = c.known[string(block.PrevHash)]
+	}
+	c.blocks = c.blocks[:block.Index-base+1]
+	for i := len(branch) - 1; i >= 0; i-- {
+		c.blocks = append(c.blocks, branch[i])
+	}
+}
@@ -0,0 +1,339 @@
+package BlockChain
+
+import (
+	"CHAIN/common"
+	"CHAIN/kvstore"
+	"CHAIN/params"
+	"CHAIN/statedb"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// newTestChain 创建一条给 key 对应账户预置余额的链
+func newTestChain(t *testing.T, key *ecdsa.PrivateKey) (*Chain, kvstore.KVStore) {
+	t.Helper()
+	var addr common.Address
+	copy(addr[:], crypto.PubkeyToAddress(key.PublicKey).Bytes())
+
+	db := kvstore.NewMemoryKVStore()
+	g := &Genesis{
+		Config:   params.DefaultChainConfig,
+		GasLimit: 8000000,
+		Alloc:    map[common.Address]GenesisAccount{addr: {Balance: ether}},
+	}
+	genesis, err := SetupGenesisBlock(db, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewChain(db, g.Config, genesis), db
+}
+
+var (
+	ether    = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	coinbase = common.Address{0xcb}
+)
+
+// nextBlock 返回接在链头之后、尚未包含交易的区块
+func nextBlock(chain *Chain) *Block {
+	return childBlock(chain, chain.CurrentBlock())
+}
+
+// childBlock 返回接在 parent 之后、尚未包含交易的区块
+func childBlock(chain *Chain, parent *Block) *Block {
+	block := NewBlock(nil, parent.Hash, parent.Index+1)
+	block.GasLimit = parent.GasLimit
+	block.Coinbase = coinbase
+	block.StateRoot = parent.StateRoot
+	if chain.Config().IsLondon(block.Index) {
+		block.BaseFee = CalcBaseFee(chain.Config(), parent)
+	}
+	return block
+}
+
+// buildBlock 在链头状态上执行交易并生成新区块
+func buildBlock(t *testing.T, chain *Chain, db kvstore.KVStore, txs ...*common.Transaction) *Block {
+	t.Helper()
+	return buildBlockOn(t, chain, db, chain.CurrentBlock(), txs...)
+}
+
+// buildBlockOn 在 parent 的状态上执行交易并生成 parent 的子区块
+func buildBlockOn(t *testing.T, chain *Chain, db kvstore.KVStore, parent *Block, txs ...*common.Transaction) *Block {
+	t.Helper()
+	block := childBlock(chain, parent)
+	state, err := statedb.New(block.StateRoot, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := common.MakeSigner(chain.Config(), block.Index)
+	for _, tx := range txs {
+		gas, err := ApplyTransaction(state, signer, block, tx)
+		if err != nil {
+			t.Fatalf("ApplyTransaction failed: %v", err)
+		}
+		block.GasUsed += gas
+	}
+	if block.StateRoot, err = state.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	block.Transactions = txs
+	block.Hash = block.CalculateHash()
+	return block
+}
+
+func signedTransfer(t *testing.T, key *ecdsa.PrivateKey, nonce uint64, value int64) *common.Transaction {
+	t.Helper()
+	to := common.Address{9}
+	tx := &common.Transaction{To: &to, Nonce: nonce, Value: big.NewInt(value), GasLimit: 21000, GasPrice: big.NewInt(2 * int64(params.InitialBaseFee))}
+	if _, err := common.NewEIP155Signer(params.DefaultChainConfig.ChainID).SignTx(tx, key); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func TestInsertBlock(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	chain, db := newTestChain(t, key)
+
+	block := buildBlock(t, chain, db, signedTransfer(t, key, 1, 100), signedTransfer(t, key, 2, 50))
+	if err := chain.InsertBlock(block); err != nil {
+		t.Fatalf("InsertBlock failed: %v", err)
+	}
+	if chain.CurrentBlock() != block || chain.Len() != 2 {
+		t.Fatal("chain head not updated")
+	}
+	state, _ := chain.State()
+	if got := state.GetBalance(common.Address{9}); got.Int64() != 150 {
+		t.Fatalf("recipient balance = %s, want 150", got)
+	}
+
+	// 父区块不匹配
+	orphan := NewBlock(nil, []byte("unknown"), block.Index+1)
+	if err := chain.InsertBlock(orphan); !errors.Is(err, ErrUnknownParent) {
+		t.Fatalf("InsertBlock orphan = %v, want ErrUnknownParent", err)
+	}
+}
+
+func TestInsertBlockRejectsInvalidTransactions(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	chain, db := newTestChain(t, key)
+
+	// 签名被篡改的交易：导入器返回错误而不是崩溃
+	junk := signedTransfer(t, key, 1, 100)
+	junk.V = big.NewInt(7)
+	block := nextBlock(chain)
+	block.Transactions = []*common.Transaction{junk}
+	err := chain.InsertBlock(block)
+	if !errors.Is(err, ErrInvalidTransaction) || !errors.Is(err, ErrInvalidSender) || !errors.Is(err, common.ErrInvalidSig) {
+		t.Fatalf("InsertBlock with junk tx = %v, want ErrInvalidSender", err)
+	}
+
+	// 金额或价格字段为负数的交易无法编码，同样返回错误
+	negatives := map[string]func(tx *common.Transaction){
+		"value":       func(tx *common.Transaction) { tx.Value = big.NewInt(-1) },
+		"gas price":   func(tx *common.Transaction) { tx.GasPrice = big.NewInt(-1) },
+		"gas fee cap": func(tx *common.Transaction) { tx.GasFeeCap = big.NewInt(-1) },
+		"gas tip cap": func(tx *common.Transaction) { tx.GasTipCap = big.NewInt(-1) },
+	}
+	for name, set := range negatives {
+		tx := signedTransfer(t, key, 1, 100)
+		set(tx)
+		block := nextBlock(chain)
+		block.Transactions = []*common.Transaction{tx}
+		if err := chain.InsertBlock(block); !errors.Is(err, ErrInvalidTransaction) || !errors.Is(err, common.ErrNegativeValue) {
+			t.Fatalf("%s: InsertBlock = %v, want ErrNegativeValue", name, err)
+		}
+	}
+
+	// 状态根与执行结果不一致
+	bad := buildBlock(t, chain, db, signedTransfer(t, key, 1, 100))
+	bad.StateRoot = common.Hash{1}
+	if err := chain.InsertBlock(bad); !errors.Is(err, ErrStateRootMismatch) {
+		t.Fatalf("InsertBlock with wrong root = %v, want ErrStateRootMismatch", err)
+	}
+	// Gas 用量与执行结果不一致
+	bad = buildBlock(t, chain, db, signedTransfer(t, key, 1, 100))
+	bad.GasUsed++
+	if err := chain.InsertBlock(bad); !errors.Is(err, ErrGasUsedMismatch) {
+		t.Fatalf("InsertBlock with wrong gas used = %v, want ErrGasUsedMismatch", err)
+	}
+
+	// 基础费用与父区块计算结果不一致
+	bad = buildBlock(t, chain, db)
+	bad.BaseFee = new(big.Int).Add(bad.BaseFee, big.NewInt(1))
+	if err := chain.InsertBlock(bad); !errors.Is(err, ErrInvalidBaseFee) {
+		t.Fatalf("InsertBlock with wrong base fee = %v, want ErrInvalidBaseFee", err)
+	}
+	if chain.Len() != 1 {
+		t.Fatal("rejected blocks must not extend the chain")
+	}
+}
+
+func TestInsertBlockSideChain(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	chain, db := newTestChain(t, key)
+	genesis := chain.CurrentBlock()
+
+	a1 := buildBlock(t, chain, db, signedTransfer(t, key, 1, 100))
+	if err := chain.InsertBlock(a1); err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.InsertBlock(a1); !errors.Is(err, ErrKnownBlock) {
+		t.Fatalf("InsertBlock twice = %v, want ErrKnownBlock", err)
+	}
+
+	// 同一高度的分叉区块作为侧链保存，不改变链头
+	b1 := buildBlockOn(t, chain, db, genesis, signedTransfer(t, key, 1, 300))
+	if err := chain.InsertBlock(b1); err != nil {
+		t.Fatalf("InsertBlock side chain block failed: %v", err)
+	}
+	if chain.CurrentBlock() != a1 || chain.GetBlock(b1.Hash) != b1 {
+		t.Fatal("side chain block changed the head or was not stored")
+	}
+
+	// 侧链变得更长后成为规范链，被丢弃的区块仍可读取
+	b2 := buildBlockOn(t, chain, db, b1, signedTransfer(t, key, 2, 5))
+	if err := chain.InsertBlock(b2); err != nil {
+		t.Fatalf("InsertBlock on side chain failed: %v", err)
+	}
+	if chain.CurrentBlock() != b2 || chain.Len() != 3 || chain.GetBlock(a1.Hash) != a1 {
+		t.Fatalf("head = %d, len = %d, want reorg to side chain", chain.CurrentBlock().Index, chain.Len())
+	}
+	state, _ := chain.State()
+	if got := state.GetBalance(common.Address{9}); got.Int64() != 305 {
+		t.Fatalf("recipient balance = %s, want 305", got)
+	}
+}
+
+func TestCalcBaseFee(t *testing.T) {
+	config := params.DefaultChainConfig
+	initial := int64(params.InitialBaseFee)
+	tests := []struct {
+		gasLimit, gasUsed uint64
+		want              int64
+	}{
+		{20000000, 10000000, initial},            // 用量等于目标，不变
+		{20000000, 9000000, initial - 12500000},  // 低于目标，下调
+		{20000000, 11000000, initial + 12500000}, // 高于目标，上调
+		{20000000, 20000000, initial * 9 / 8},    // 满块上调 1/8
+		{20000000, 0, initial * 7 / 8},           // 空块下调 1/8
+	}
+	for _, test := range tests {
+		parent := &Block{Index: 1, GasLimit: test.gasLimit, GasUsed: test.gasUsed, BaseFee: big.NewInt(initial)}
+		if got := CalcBaseFee(config, parent); got.Int64() != test.want {
+			t.Errorf("gasUsed %d: base fee = %v, want %d", test.gasUsed, got, test.want)
+		}
+	}
+
+	// 父区块尚未启用 London 时使用初始基础费用
+	late := &params.ChainConfig{ChainID: big.NewInt(1), LondonBlock: big.NewInt(5)}
+	if got := CalcBaseFee(late, &Block{Index: 4, GasLimit: 100}); got.Uint64() != params.InitialBaseFee {
+		t.Errorf("first London block base fee = %v, want %d", got, params.InitialBaseFee)
+	}
+}
+
+func TestApplyTransactionBurnsBaseFee(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	var from common.Address
+	copy(from[:], crypto.PubkeyToAddress(key.PublicKey).Bytes())
+	to := common.Address{9}
+	signer := common.NewLondonSigner(params.DefaultChainConfig.ChainID)
+	block := &Block{Index: 1, Coinbase: coinbase, BaseFee: big.NewInt(100)}
+
+	newTx := func(nonce uint64, tipCap, feeCap int64, gas uint64) *common.Transaction {
+		tx := &common.Transaction{
+			Type: common.DynamicFeeTxType, Nonce: nonce, GasTipCap: big.NewInt(tipCap), GasFeeCap: big.NewInt(feeCap),
+			GasLimit: gas, To: &to, Value: big.NewInt(1000),
+		}
+		if _, err := signer.SignTx(tx, key); err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+
+	state := statedb.NewInMemoryStateDB()
+	state.AddBalance(from, ether)
+
+	// 有效价格 = min(feeCap, baseFee+tip) = 130，小费 30 付给出块者，100 被销毁
+	gas, err := ApplyTransaction(state, signer, block, newTx(1, 50, 130, 30000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gas != params.TxGas {
+		t.Fatalf("gas used = %d, want %d", gas, params.TxGas)
+	}
+	wantSender := new(big.Int).Sub(ether, big.NewInt(1000+130*21000))
+	if got := state.GetBalance(from); got.Cmp(wantSender) != 0 {
+		t.Fatalf("sender balance = %v, want %v", got, wantSender)
+	}
+	if got := state.GetBalance(coinbase); got.Int64() != 30*21000 {
+		t.Fatalf("coinbase balance = %v, want %d", got, 30*21000)
+	}
+	if got := state.GetBalance(to); got.Int64() != 1000 {
+		t.Fatalf("recipient balance = %v, want 1000", got)
+	}
+
+	invalid := map[error]*common.Transaction{
+		common.ErrFeeCapTooLow: newTx(2, 1, 99, 21000),
+		ErrTipAboveFeeCap:      newTx(2, 200, 150, 21000),
+		ErrIntrinsicGas:        newTx(2, 1, 200, 20999),
+	}
+	for want, tx := range invalid {
+		if _, err := ApplyTransaction(state, signer, block, tx); !errors.Is(err, want) {
+			t.Errorf("ApplyTransaction = %v, want %v", err, want)
+		}
+	}
+	if state.GetNonce(from) != 1 {
+		t.Fatal("rejected transactions must not change the nonce")
+	}
+}
+
+func TestIntrinsicGas(t *testing.T) {
+	accessList := common.AccessList{
+		{Address: common.Address{1}, StorageKeys: []common.Hash{{1}, {2}}},
+		{Address: common.Address{2}},
+	}
+	want := params.TxGas + params.TxDataZeroGas + params.TxDataNonZeroGas +
+		2*params.TxAccessListAddressGas + 2*params.TxAccessListStorageKeyGas
+	if got := IntrinsicGas([]byte{0, 1}, accessList); got != want {
+		t.Fatalf("IntrinsicGas = %d, want %d", got, want)
+	}
+}
+
+func TestInsertBlockWithTypedTransactions(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	chain, db := newTestChain(t, key)
+	signer := common.MakeSigner(chain.Config(), 1)
+	to := common.Address{9}
+
+	accessListTx := &common.Transaction{
+		Type: common.AccessListTxType, Nonce: 1, GasPrice: big.NewInt(2 * int64(params.InitialBaseFee)),
+		GasLimit: 30000, To: &to, Value: big.NewInt(7),
+		AccessList: common.AccessList{{Address: to, StorageKeys: []common.Hash{{1}}}},
+	}
+	dynamicTx := &common.Transaction{
+		Type: common.DynamicFeeTxType, Nonce: 2, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2 * int64(params.InitialBaseFee)),
+		GasLimit: 21000, To: &to, Value: big.NewInt(8),
+	}
+	for _, tx := range []*common.Transaction{accessListTx, dynamicTx} {
+		if _, err := signer.SignTx(tx, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	block := buildBlock(t, chain, db, accessListTx, dynamicTx)
+	wantGas := 2*params.TxGas + params.TxAccessListAddressGas + params.TxAccessListStorageKeyGas
+	if block.GasUsed != wantGas {
+		t.Fatalf("gas used = %d, want %d", block.GasUsed, wantGas)
+	}
+	if err := chain.InsertBlock(block); err != nil {
+		t.Fatalf("InsertBlock failed: %v", err)
+	}
+	state, _ := chain.State()
+	if got := state.GetBalance(to); got.Int64() != 15 {
+		t.Fatalf("recipient balance = %v, want 15", got)
+	}
+}
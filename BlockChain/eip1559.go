@@ -0,0 +1,72 @@
+package BlockChain
+
+import (
+	"CHAIN/params"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	// ErrInvalidBaseFee 表示区块的基础费用与按父区块计算的值不一致
+	ErrInvalidBaseFee = errors.New("invalid base fee")
+	// ErrGasLimitReached 表示区块内交易的 Gas 总量超过区块 Gas 上限
+	ErrGasLimitReached = errors.New("gas limit reached")
+	// ErrGasUsedMismatch 表示执行得到的 Gas 用量与区块记录不一致
+	ErrGasUsedMismatch = errors.New("gas used mismatch")
+)
+
+// CalcBaseFee 按 EIP-1559 根据父区块的 Gas 用量计算子区块的基础费用：
+// 用量高于目标（GasLimit/2）时上调，低于目标时下调，每个区块最多变化 1/8。
+// 父区块尚未启用 London 时返回初始基础费用
+func CalcBaseFee(config *params.ChainConfig, parent *Block) *big.Int {
+	if !config.IsLondon(parent.Index) || parent.BaseFee == nil {
+		return new(big.Int).SetUint64(params.InitialBaseFee)
+	}
+
+	parentGasTarget := parent.GasLimit / params.ElasticityMultiplier
+	if parentGasTarget == 0 || parent.GasUsed == parentGasTarget {
+		return new(big.Int).Set(parent.BaseFee)
+	}
+
+	var (
+		num   = new(big.Int)
+		denom = new(big.Int)
+	)
+	if parent.GasUsed > parentGasTarget {
+		// baseFee + max(1, baseFee * gasUsedDelta / target / 8)
+		num.SetUint64(parent.GasUsed - parentGasTarget)
+		num.Mul(num, parent.BaseFee)
+		num.Div(num, denom.SetUint64(parentGasTarget))
+		num.Div(num, denom.SetUint64(params.BaseFeeChangeDenominator))
+		if num.Sign() == 0 {
+			num.SetUint64(1)
+		}
+		return num.Add(num, parent.BaseFee)
+	}
+	// max(0, baseFee - baseFee * gasUsedDelta / target / 8)
+	num.SetUint64(parentGasTarget - parent.GasUsed)
+	num.Mul(num, parent.BaseFee)
+	num.Div(num, denom.SetUint64(parentGasTarget))
+	num.Div(num, denom.SetUint64(params.BaseFeeChangeDenominator))
+	baseFee := num.Sub(parent.BaseFee, num)
+	if baseFee.Sign() < 0 {
+		baseFee.SetUint64(0)
+	}
+	return baseFee
+}
+
+// verifyBaseFee 校验区块的基础费用：London 之前必须为空，之后必须等于 CalcBaseFee
+func verifyBaseFee(config *params.ChainConfig, parent, block *Block) error {
+	if !config.IsLondon(block.Index) {
+		if block.BaseFee != nil {
+			return fmt.Errorf("%w: have %v, want <nil>", ErrInvalidBaseFee, block.BaseFee)
+		}
+		return nil
+	}
+	expected := CalcBaseFee(config, parent)
+	if block.BaseFee == nil || block.BaseFee.Cmp(expected) != 0 {
+		return fmt.Errorf("%w: have %v, want %v", ErrInvalidBaseFee, block.BaseFee, expected)
+	}
+	return nil
+}
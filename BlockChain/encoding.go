@@ -0,0 +1,253 @@
+package BlockChain
+
+import (
+	"CHAIN/common"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// 区块、区块头与区块体的规范编码为 version || rlp(...)，
+// 修改编码格式时递增版本号，旧版本的数据可以据此识别
+const (
+	// BlockEncodingV1 的区块头为
+	// rlp([index, timestamp, prevHash, nonce, stateRoot, difficulty, gasLimit, gasUsed, coinbase, txHash, baseFee?])，
+	// 区块体为 rlp([[tx...]])，区块为 rlp([header, [tx...]])，交易使用 Transaction 的规范编码
+	BlockEncodingV1 byte = 1
+
+	currentBlockEncoding = BlockEncodingV1
+)
+
+var (
+	// ErrUnsupportedEncoding 表示编码为空或版本号未知
+	ErrUnsupportedEncoding = errors.New("unsupported block encoding version")
+	// ErrTxHashMismatch 表示区块体中的交易与区块头记录的交易哈希不符
+	ErrTxHashMismatch = errors.New("transaction hash mismatch")
+)
+
+// Header 是区块头，区块哈希即区块头规范编码的 keccak256 哈希
+type Header struct {
+	Index      uint64
+	Timestamp  int64
+	PrevHash   []byte
+	Nonce      uint64
+	StateRoot  common.Hash
+	Difficulty uint64
+	GasLimit   uint64
+	GasUsed    uint64
+	Coinbase   common.Address
+	TxHash     common.Hash // 区块内交易哈希列表的 keccak256 哈希
+	BaseFee    *big.Int    // London 之前为 nil，编码时省略
+}
+
+// headerRLP 是区块头的编码结构，时间戳按 uint64 编码
+type headerRLP struct {
+	Index      uint64
+	Timestamp  uint64
+	PrevHash   []byte
+	Nonce      uint64
+	StateRoot  common.Hash
+	Difficulty uint64
+	GasLimit   uint64
+	GasUsed    uint64
+	Coinbase   common.Address
+	TxHash     common.Hash
+	BaseFee    *big.Int `rlp:"optional"`
+}
+
+// Body 是区块体，即区块中的交易列表，区块头的 TxHash 由它计算
+type Body struct {
+	Transactions []*common.Transaction
+}
+
+// bodyRLP 是区块体的编码结构
+type bodyRLP struct {
+	Transactions []*common.Transaction
+}
+
+// blockRLP 是区块的编码结构：区块头与区块体（交易列表）
+type blockRLP struct {
+	Header       headerRLP
+	Transactions []*common.Transaction
+}
+
+// Header 返回区块的区块头
+func (b *Block) Header() *Header {
+	return &Header{
+		Index:      b.Index,
+		Timestamp:  b.Timestamp,
+		PrevHash:   b.PrevHash,
+		Nonce:      b.Nonce,
+		StateRoot:  b.StateRoot,
+		Difficulty: b.Difficulty,
+		GasLimit:   b.GasLimit,
+		GasUsed:    b.GasUsed,
+		Coinbase:   b.Coinbase,
+		TxHash:     DeriveTxHash(b.Transactions),
+		BaseFee:    b.BaseFee,
+	}
+}
+
+// Body 返回区块的区块体
+func (b *Block) Body() *Body {
+	return &Body{Transactions: b.Transactions}
+}
+
+// Hash 返回区块头规范编码的 keccak256 哈希
+func (h *Header) Hash() []byte {
+	return crypto.Keccak256(h.encode())
+}
+
+// EncodeHeader 返回区块头的规范编码
+func EncodeHeader(h *Header) []byte {
+	return h.encode()
+}
+
+// DecodeHeader 解码 EncodeHeader 生成的区块头
+func DecodeHeader(data []byte) (*Header, error) {
+	payload, err := encodingPayload(data)
+	if err != nil {
+		return nil, err
+	}
+	var dec headerRLP
+	if err := rlp.DecodeBytes(payload, &dec); err != nil {
+		return nil, fmt.Errorf("decode header: %w", err)
+	}
+	return dec.header(), nil
+}
+
+// EncodeBody 返回区块体的规范编码，区块头与区块体可以分开存储和传输
+func EncodeBody(b *Body) ([]byte, error) {
+	enc, err := rlp.EncodeToBytes(&bodyRLP{Transactions: b.Transactions})
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{currentBlockEncoding}, enc...), nil
+}
+
+// DecodeBody 解码 EncodeBody 生成的区块体。
+// 区块体不包含区块头，调用方应以 DeriveTxHash 校验它与区块头的 TxHash 一致
+func DecodeBody(data []byte) (*Body, error) {
+	payload, err := encodingPayload(data)
+	if err != nil {
+		return nil, err
+	}
+	var dec bodyRLP
+	if err := rlp.DecodeBytes(payload, &dec); err != nil {
+		return nil, fmt.Errorf("decode body: %w", err)
+	}
+	return &Body{Transactions: dec.Transactions}, nil
+}
+
+// EncodeBlock 返回区块（区块头与交易）的规范编码，用于存储和网络传输
+func EncodeBlock(b *Block) ([]byte, error) {
+	enc, err := rlp.EncodeToBytes(&blockRLP{
+		Header:       b.Header().rlp(),
+		Transactions: b.Transactions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{currentBlockEncoding}, enc...), nil
+}
+
+// DecodeBlock 解码 EncodeBlock 生成的区块，校验交易与区块头一致并重新计算区块哈希
+func DecodeBlock(data []byte) (*Block, error) {
+	payload, err := encodingPayload(data)
+	if err != nil {
+		return nil, err
+	}
+	var dec blockRLP
+	if err := rlp.DecodeBytes(payload, &dec); err != nil {
+		return nil, fmt.Errorf("decode block: %w", err)
+	}
+	h := dec.Header.header()
+	if txHash := DeriveTxHash(dec.Transactions); txHash != h.TxHash {
+		return nil, fmt.Errorf("%w: body %s, header %s", ErrTxHashMismatch, txHash, h.TxHash)
+	}
+	block := &Block{
+		Index:        h.Index,
+		Timestamp:    h.Timestamp,
+		PrevHash:     h.PrevHash,
+		Nonce:        h.Nonce,
+		StateRoot:    h.StateRoot,
+		Difficulty:   h.Difficulty,
+		GasLimit:     h.GasLimit,
+		GasUsed:      h.GasUsed,
+		BaseFee:      h.BaseFee,
+		Coinbase:     h.Coinbase,
+		Transactions: dec.Transactions,
+	}
+	block.Hash = h.Hash()
+	return block, nil
+}
+
+// DeriveTxHash 返回交易列表的哈希：keccak256(rlp([txHash...]))。
+// 无法编码的交易按空哈希计入，这样的区块会在执行交易时被拒绝
+func DeriveTxHash(txs []*common.Transaction) common.Hash {
+	hashes := make([][]byte, len(txs))
+	for i, tx := range txs {
+		hashes[i], _ = tx.Hash()
+	}
+	enc, err := rlp.EncodeToBytes(hashes)
+	if err != nil {
+		// 字节串列表总是可以编码
+		panic(fmt.Sprintf("rlp encoding failed: %v", err))
+	}
+	return common.BytesToHash(crypto.Keccak256(enc))
+}
+
+func (h *Header) encode() []byte {
+	enc, err := rlp.EncodeToBytes(h.rlp())
+	if err != nil {
+		// 区块头只包含基础类型，出错说明代码有误
+		panic(fmt.Sprintf("rlp encoding failed: %v", err))
+	}
+	return append([]byte{currentBlockEncoding}, enc...)
+}
+
+func (h *Header) rlp() headerRLP {
+	return headerRLP{
+		Index:      h.Index,
+		Timestamp:  uint64(h.Timestamp),
+		PrevHash:   h.PrevHash,
+		Nonce:      h.Nonce,
+		StateRoot:  h.StateRoot,
+		Difficulty: h.Difficulty,
+		GasLimit:   h.GasLimit,
+		GasUsed:    h.GasUsed,
+		Coinbase:   h.Coinbase,
+		TxHash:     h.TxHash,
+		BaseFee:    h.BaseFee,
+	}
+}
+
+func (dec *headerRLP) header() *Header {
+	return &Header{
+		Index:      dec.Index,
+		Timestamp:  int64(dec.Timestamp),
+		PrevHash:   dec.PrevHash,
+		Nonce:      dec.Nonce,
+		StateRoot:  dec.StateRoot,
+		Difficulty: dec.Difficulty,
+		GasLimit:   dec.GasLimit,
+		GasUsed:    dec.GasUsed,
+		Coinbase:   dec.Coinbase,
+		TxHash:     dec.TxHash,
+		BaseFee:    dec.BaseFee,
+	}
+}
+
+// encodingPayload 校验版本号并返回其后的 RLP 数据
+func encodingPayload(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: empty input", ErrUnsupportedEncoding)
+	}
+	if data[0] != BlockEncodingV1 {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedEncoding, data[0])
+	}
+	return data[1:], nil
+}
@@ -0,0 +1,268 @@
+package BlockChain
+
+import (
+	"CHAIN/common"
+	"CHAIN/params"
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// encodingTestBlock 返回包含三种交易类型的区块
+func encodingTestBlock(t testing.TB) *Block {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.Address{9}
+	signer := common.NewLondonSigner(params.DefaultChainConfig.ChainID)
+	txs := []*common.Transaction{
+		{To: &to, Nonce: 1, Value: big.NewInt(1), GasLimit: 21000, GasPrice: big.NewInt(3)},
+		{Type: common.AccessListTxType, Nonce: 2, GasLimit: 30000, GasPrice: big.NewInt(3), Input: []byte{1, 2},
+			AccessList: common.AccessList{{Address: to, StorageKeys: []common.Hash{{1}}}}},
+		{Type: common.DynamicFeeTxType, To: &to, Nonce: 3, GasLimit: 21000, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(5)},
+	}
+	for _, tx := range txs {
+		if _, err := signer.SignTx(tx, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	block := &Block{
+		Index:        7,
+		Timestamp:    1700000000,
+		PrevHash:     bytes.Repeat([]byte{0xab}, 32),
+		Nonce:        42,
+		StateRoot:    common.Hash{1, 2, 3},
+		Difficulty:   1,
+		GasLimit:     8000000,
+		GasUsed:      72000,
+		BaseFee:      new(big.Int).SetUint64(params.InitialBaseFee),
+		Coinbase:     common.Address{0xc0},
+		Transactions: txs,
+	}
+	block.Hash = block.CalculateHash()
+	return block
+}
+
+func TestEncodeBlockRoundTrip(t *testing.T) {
+	block := encodingTestBlock(t)
+	enc, err := EncodeBlock(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc[0] != BlockEncodingV1 {
+		t.Fatalf("encoding version = %d, want %d", enc[0], BlockEncodingV1)
+	}
+	dec, err := DecodeBlock(enc)
+	if err != nil {
+		t.Fatalf("DecodeBlock failed: %v", err)
+	}
+	if !bytes.Equal(dec.Hash, block.Hash) {
+		t.Fatalf("decoded hash %x, want %x", dec.Hash, block.Hash)
+	}
+	if dec.Timestamp != block.Timestamp || dec.BaseFee.Cmp(block.BaseFee) != 0 || dec.Coinbase != block.Coinbase {
+		t.Fatalf("decoded header = %+v", dec.Header())
+	}
+	for i, tx := range dec.Transactions {
+		have, _ := tx.Hash()
+		want, _ := block.Transactions[i].Hash()
+		if !bytes.Equal(have, want) || tx.Type != block.Transactions[i].Type {
+			t.Errorf("tx %d changed after round trip", i)
+		}
+	}
+	again, err := EncodeBlock(dec)
+	if err != nil || !bytes.Equal(again, enc) {
+		t.Fatalf("re-encoding differs: %v", err)
+	}
+
+	// 区块头单独编码，哈希与区块哈希一致
+	header, err := DecodeHeader(EncodeHeader(block.Header()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(header.Hash(), block.Hash) {
+		t.Fatalf("header hash %x, want %x", header.Hash(), block.Hash)
+	}
+}
+
+func TestEncodeBodyRoundTrip(t *testing.T) {
+	block := encodingTestBlock(t)
+	enc, err := EncodeBody(block.Body())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc[0] != BlockEncodingV1 {
+		t.Fatalf("encoding version = %d, want %d", enc[0], BlockEncodingV1)
+	}
+	body, err := DecodeBody(enc)
+	if err != nil {
+		t.Fatalf("DecodeBody failed: %v", err)
+	}
+	// 单独传输的区块体与区块头的交易哈希一致
+	if DeriveTxHash(body.Transactions) != block.Header().TxHash {
+		t.Fatal("decoded body does not match the header")
+	}
+	again, err := EncodeBody(body)
+	if err != nil || !bytes.Equal(again, enc) {
+		t.Fatalf("re-encoding differs: %v", err)
+	}
+
+	if _, err := DecodeBody(nil); !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("empty input = %v, want ErrUnsupportedEncoding", err)
+	}
+	future := append([]byte{BlockEncodingV1 + 1}, enc[1:]...)
+	if _, err := DecodeBody(future); !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("unknown version = %v, want ErrUnsupportedEncoding", err)
+	}
+	if _, err := DecodeBody(append(enc, 0)); err == nil {
+		t.Error("trailing data accepted")
+	}
+}
+
+func TestBlockHashCoversHeader(t *testing.T) {
+	block := encodingTestBlock(t)
+
+	preLondon := *block
+	preLondon.BaseFee = nil
+	zeroFee := *block
+	zeroFee.BaseFee = new(big.Int)
+	fewerTxs := *block
+	fewerTxs.Transactions = block.Transactions[:2]
+	coinbase := *block
+	coinbase.Coinbase = common.Address{0xc1}
+
+	for name, b := range map[string]*Block{"nil base fee": &preLondon, "zero base fee": &zeroFee, "fewer txs": &fewerTxs, "coinbase": &coinbase} {
+		if bytes.Equal(b.CalculateHash(), block.Hash) {
+			t.Errorf("%s: hash unchanged", name)
+		}
+	}
+	if bytes.Equal(preLondon.CalculateHash(), zeroFee.CalculateHash()) {
+		t.Error("nil and zero base fee hash equally")
+	}
+}
+
+func TestDecodeBlockErrors(t *testing.T) {
+	block := encodingTestBlock(t)
+	enc, err := EncodeBlock(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DecodeBlock(nil); !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("empty input = %v, want ErrUnsupportedEncoding", err)
+	}
+	future := append([]byte{BlockEncodingV1 + 1}, enc[1:]...)
+	if _, err := DecodeBlock(future); !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("unknown version = %v, want ErrUnsupportedEncoding", err)
+	}
+	if _, err := DecodeBlock(append(enc, 0)); err == nil {
+		t.Error("trailing data accepted")
+	}
+	if _, err := DecodeBlock(enc[:len(enc)-1]); err == nil {
+		t.Error("truncated block accepted")
+	}
+
+	// 区块体被替换后与区块头的交易哈希不符
+	forged, err := rlp.EncodeToBytes(&blockRLP{Header: block.Header().rlp(), Transactions: block.Transactions[:1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged = append([]byte{BlockEncodingV1}, forged...)
+	if _, err := DecodeBlock(forged); !errors.Is(err, ErrTxHashMismatch) {
+		t.Errorf("forged body = %v, want ErrTxHashMismatch", err)
+	}
+}
+
+func FuzzDecodeBlock(f *testing.F) {
+	block := encodingTestBlock(f)
+	enc, err := EncodeBlock(block)
+	if err != nil {
+		f.Fatal(err)
+	}
+	empty, _ := EncodeBlock(&Block{})
+	f.Add(enc)
+	f.Add(empty)
+	f.Add([]byte{BlockEncodingV1})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		block, err := DecodeBlock(data)
+		if err != nil {
+			return
+		}
+		// 能解码的输入必须是规范编码
+		again, err := EncodeBlock(block)
+		if err != nil {
+			t.Fatalf("decoded block cannot be encoded: %v", err)
+		}
+		if !bytes.Equal(again, data) {
+			t.Fatalf("non-canonical encoding accepted:\n in %x\nout %x", data, again)
+		}
+	})
+}
+
+func FuzzDecodeBody(f *testing.F) {
+	block := encodingTestBlock(f)
+	enc, err := EncodeBody(block.Body())
+	if err != nil {
+		f.Fatal(err)
+	}
+	empty, _ := EncodeBody(&Body{})
+	f.Add(enc)
+	f.Add(empty)
+	f.Add([]byte{BlockEncodingV1})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		body, err := DecodeBody(data)
+		if err != nil {
+			return
+		}
+		// 能解码的输入必须是规范编码
+		again, err := EncodeBody(body)
+		if err != nil {
+			t.Fatalf("decoded body cannot be encoded: %v", err)
+		}
+		if !bytes.Equal(again, data) {
+			t.Fatalf("non-canonical encoding accepted:\n in %x\nout %x", data, again)
+		}
+	})
+}
+
+func FuzzBlockRoundTrip(f *testing.F) {
+	f.Add(uint64(1), int64(1700000000), []byte("parent"), uint64(0), uint64(8000000), uint64(21000), []byte{1}, true, []byte("data"))
+	f.Add(uint64(0), int64(-1), []byte{}, uint64(0), uint64(0), uint64(0), []byte{}, false, []byte{})
+
+	f.Fuzz(func(t *testing.T, index uint64, timestamp int64, prevHash []byte, nonce, gasLimit, gasUsed uint64, baseFee []byte, london bool, input []byte) {
+		to := common.Address{9}
+		block := &Block{
+			Index:     index,
+			Timestamp: timestamp,
+			PrevHash:  prevHash,
+			Nonce:     nonce,
+			GasLimit:  gasLimit,
+			GasUsed:   gasUsed,
+			Transactions: []*common.Transaction{
+				{To: &to, Nonce: nonce, GasLimit: gasLimit, Input: input, GasPrice: new(big.Int).SetBytes(baseFee)},
+			},
+		}
+		if london {
+			block.BaseFee = new(big.Int).SetBytes(baseFee)
+		}
+		block.Hash = block.CalculateHash()
+
+		enc, err := EncodeBlock(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec, err := DecodeBlock(enc)
+		if err != nil {
+			t.Fatalf("DecodeBlock failed: %v", err)
+		}
+		if !bytes.Equal(dec.Hash, block.Hash) || dec.Timestamp != timestamp || (dec.BaseFee == nil) != !london {
+			t.Fatalf("round trip changed block: %+v", dec.Header())
+		}
+	})
+}
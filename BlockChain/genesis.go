@@ -0,0 +1,196 @@
+package BlockChain
+
+import (
+	"CHAIN/common"
+	"CHAIN/kvstore"
+	"CHAIN/params"
+	"CHAIN/statedb"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+)
+
+var (
+	// genesisHashKey 记录数据库初始化时使用的创世区块哈希
+	genesisHashKey = []byte("genesis-hash")
+	// chainConfigKey 记录当前使用的链配置
+	chainConfigKey = []byte("chain-config")
+)
+
+// ErrGenesisMismatch 表示数据库已用另一个创世配置初始化
+var ErrGenesisMismatch = errors.New("genesis block does not match the one stored in database")
+
+// Genesis 是创世配置文件的内容
+type Genesis struct {
+	Config     *params.ChainConfig               `json:"config"`
+	Timestamp  int64                             `json:"timestamp"`
+	Difficulty uint64                            `json:"difficulty"`
+	GasLimit   uint64                            `json:"gasLimit"`
+	BaseFee    *big.Int                          `json:"baseFeePerGas,omitempty"` // 为空时使用 params.InitialBaseFee
+	Alloc      map[common.Address]GenesisAccount `json:"alloc"`                   // 键为十六进制地址
+}
+
+// GenesisAccount 是创世状态中预置的账户
+type GenesisAccount struct {
+	Balance *big.Int          `json:"balance"`
+	Nonce   uint64            `json:"nonce,omitempty"`
+	Code    string            `json:"code,omitempty"` // 十六进制合约代码
+	Storage map[string]string `json:"storage,omitempty"`
+}
+
+// LoadGenesis 从 JSON 文件读取创世配置
+func LoadGenesis(path string) (*Genesis, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var g Genesis
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, fmt.Errorf("invalid genesis file %s: %w", path, err)
+	}
+	return &g, nil
+}
+
+// DefaultGenesis 返回本地开发用的默认创世配置
+func DefaultGenesis() *Genesis {
+	return &Genesis{
+		Config:     params.DefaultChainConfig,
+		Difficulty: 1,
+		GasLimit:   8000000,
+		Alloc: map[common.Address]GenesisAccount{
+			common.HexToAddress("0x0102030000000000000000000000000000000000"): {Balance: big.NewInt(1000)},
+			common.HexToAddress("0x0405060000000000000000000000000000000000"): {Balance: big.NewInt(0)},
+		},
+	}
+}
+
+// Fund 给每个地址分配 balance 的创世余额，已有的分配会被覆盖
+func (g *Genesis) Fund(balance *big.Int, addrs ...common.Address) {
+	if g.Alloc == nil {
+		g.Alloc = make(map[common.Address]GenesisAccount)
+	}
+	for _, addr := range addrs {
+		g.Alloc[addr] = GenesisAccount{Balance: new(big.Int).Set(balance)}
+	}
+}
+
+// ToBlock 把预置账户写入 db 中的状态树，返回带有状态根的创世区块
+func (g *Genesis) ToBlock(db kvstore.KVStore) (*Block, error) {
+	stateDB, err := statedb.New(common.Hash{}, db)
+	if err != nil {
+		return nil, err
+	}
+	for addr, account := range g.Alloc {
+		code, err := hex.DecodeString(strings.TrimPrefix(account.Code, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid code for genesis account %s: %w", addr, err)
+		}
+
+		stateDB.CreateAccount(addr)
+		if account.Balance != nil {
+			stateDB.AddBalance(addr, account.Balance)
+		}
+		stateDB.SetNonce(addr, account.Nonce)
+		if len(code) > 0 {
+			stateDB.SetCode(addr, code)
+		}
+		for key, value := range account.Storage {
+			stateDB.SetState(addr, key, value)
+		}
+	}
+	root, err := stateDB.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	block := &Block{
+		Index:      0,
+		Timestamp:  g.Timestamp,
+		StateRoot:  root,
+		Difficulty: g.Difficulty,
+		GasLimit:   g.GasLimit,
+	}
+	if g.Config != nil && g.Config.IsLondon(0) {
+		block.BaseFee = g.BaseFee
+		if block.BaseFee == nil {
+			block.BaseFee = new(big.Int).SetUint64(params.InitialBaseFee)
+		}
+	}
+	block.Hash = block.CalculateHash()
+	return block, nil
+}
+
+// SetupGenesisBlock 构建并提交创世区块与链配置。
+// 数据库已用其他创世区块或其他链 ID 初始化时返回 ErrGenesisMismatch，此时不写入数据库。
+// 高度大于 0 的分叉可以随配置更新；在创世区块启用的分叉会改变创世区块头
+// （如 London 决定 BaseFee），启用或取消它们同样返回 ErrGenesisMismatch。
+func SetupGenesisBlock(db kvstore.KVStore, g *Genesis) (*Block, error) {
+	if g.Config == nil || g.Config.ChainID == nil {
+		return nil, errors.New("genesis has no chain config")
+	}
+	if err := checkChainID(db, g.Config); err != nil {
+		return nil, err
+	}
+	// 先在内存中构建创世区块，校验通过后才写入 db，校验失败不会留下创世状态
+	block, err := g.ToBlock(kvstore.NewMemoryKVStore())
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := db.Get(genesisHashKey)
+	switch {
+	case errors.Is(err, kvstore.ErrNotFound):
+		if block, err = g.ToBlock(db); err != nil {
+			return nil, err
+		}
+		if err := db.Put(genesisHashKey, block.Hash); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case !bytes.Equal(stored, block.Hash):
+		return nil, fmt.Errorf("%w: have %x, new %x", ErrGenesisMismatch, stored, block.Hash)
+	}
+
+	config, err := json.Marshal(g.Config)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Put(chainConfigKey, config); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// ReadChainConfig 读取数据库中保存的链配置
+func ReadChainConfig(db kvstore.KVStore) (*params.ChainConfig, error) {
+	data, err := db.Get(chainConfigKey)
+	if err != nil {
+		return nil, err
+	}
+	var config params.ChainConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// checkChainID 确认数据库中已有的链配置与新配置的链 ID 一致
+func checkChainID(db kvstore.KVStore, config *params.ChainConfig) error {
+	stored, err := ReadChainConfig(db)
+	if errors.Is(err, kvstore.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if stored.ChainID == nil || stored.ChainID.Cmp(config.ChainID) != 0 {
+		return fmt.Errorf("%w: chain id have %v, new %v", ErrGenesisMismatch, stored.ChainID, config.ChainID)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package BlockChain
+
+import (
+	"CHAIN/common"
+	"CHAIN/hdwallet"
+	"CHAIN/kvstore"
+	"CHAIN/params"
+	"CHAIN/statedb"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testGenesisJSON = `{
+	"config": {"chainId": 1337, "eip155Block": 0},
+	"timestamp": 1700000000,
+	"difficulty": 1,
+	"gasLimit": 8000000,
+	"alloc": {
+		"0x0000000000000000000000000000000000000001": {"balance": 1000000000000000000000},
+		"0x0000000000000000000000000000000000000002": {
+			"balance": 5,
+			"nonce": 3,
+			"code": "0x6001",
+			"storage": {"slot": "value"}
+		}
+	}
+}`
+
+func TestSetupGenesisBlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "genesis.json")
+	if err := os.WriteFile(path, []byte(testGenesisJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	g, err := LoadGenesis(path)
+	if err != nil {
+		t.Fatalf("LoadGenesis failed: %v", err)
+	}
+
+	db := kvstore.NewMemoryKVStore()
+	block, err := SetupGenesisBlock(db, g)
+	if err != nil {
+		t.Fatalf("SetupGenesisBlock failed: %v", err)
+	}
+	if block.Index != 0 || block.Timestamp != 1700000000 || block.GasLimit != 8000000 {
+		t.Fatalf("unexpected genesis header: %+v", block)
+	}
+	if block.StateRoot.IsEmpty() {
+		t.Fatal("genesis state root is empty")
+	}
+
+	state, err := statedb.New(block.StateRoot, db)
+	if err != nil {
+		t.Fatalf("open genesis state failed: %v", err)
+	}
+	addr1 := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	addr2 := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	if got := state.GetBalance(addr1).String(); got != "1000000000000000000000" {
+		t.Errorf("balance of account 1 = %s", got)
+	}
+	if state.GetNonce(addr2) != 3 || string(state.GetCode(addr2)) != "\x60\x01" || state.GetState(addr2, "slot") != "value" {
+		t.Errorf("account 2 not initialised from genesis")
+	}
+
+	// 同一个创世配置可以重复打开
+	again, err := SetupGenesisBlock(db, g)
+	if err != nil {
+		t.Fatalf("reopening with same genesis failed: %v", err)
+	}
+	if string(again.Hash) != string(block.Hash) {
+		t.Fatal("genesis hash is not deterministic")
+	}
+
+	config, err := ReadChainConfig(db)
+	if err != nil || config.ChainID.Int64() != 1337 || !config.IsEIP155(0) {
+		t.Fatalf("stored chain config = %+v, %v", config, err)
+	}
+
+	// 高度大于 0 的分叉可以随配置更新，在创世区块启用的分叉改变创世区块头
+	later := *g
+	later.Config = &params.ChainConfig{ChainID: big.NewInt(1337), EIP155Block: big.NewInt(0), LondonBlock: big.NewInt(10)}
+	if _, err := SetupGenesisBlock(db, &later); err != nil {
+		t.Fatalf("enabling a later fork failed: %v", err)
+	}
+	atGenesis := *g
+	atGenesis.Config = &params.ChainConfig{ChainID: big.NewInt(1337), EIP155Block: big.NewInt(0), LondonBlock: big.NewInt(0)}
+	if _, err := SetupGenesisBlock(db, &atGenesis); !errors.Is(err, ErrGenesisMismatch) {
+		t.Fatalf("enabling London at genesis = %v, want ErrGenesisMismatch", err)
+	}
+
+	// 不同的创世配置被拒绝
+	other := DefaultGenesis()
+	if _, err := SetupGenesisBlock(db, other); !errors.Is(err, ErrGenesisMismatch) {
+		t.Fatalf("setup with different genesis = %v, want ErrGenesisMismatch", err)
+	}
+	// 被拒绝的创世状态不会写入数据库
+	otherBlock, err := other.ToBlock(kvstore.NewMemoryKVStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Get(otherBlock.StateRoot[:]); !errors.Is(err, kvstore.ErrNotFound) {
+		t.Fatalf("rejected genesis state written to db: %v", err)
+	}
+
+	// 创世状态相同但链 ID 不同同样被拒绝
+	otherChain := *g
+	otherChain.Config = &params.ChainConfig{ChainID: big.NewInt(1)}
+	if _, err := SetupGenesisBlock(db, &otherChain); !errors.Is(err, ErrGenesisMismatch) {
+		t.Fatalf("setup with different chain id = %v, want ErrGenesisMismatch", err)
+	}
+}
+
+func TestGenesisInvalidAddress(t *testing.T) {
+	for _, input := range []string{
+		`{"alloc": {"0x1234": {}}}`,
+		`{"alloc": {"0x0000000000000000000000000000000000000zzz": {}}}`,
+		// 大小写混合但校验和错误
+		`{"alloc": {"0xF39Fd6e51aad88F6F4ce6aB8827279cffFb92266": {}}}`,
+	} {
+		var g Genesis
+		if err := json.Unmarshal([]byte(input), &g); !errors.Is(err, common.ErrInvalidAddress) && !errors.Is(err, common.ErrInvalidChecksum) {
+			t.Errorf("unmarshal %s = %v, want invalid address error", input, err)
+		}
+	}
+}
+
+func TestGenesisFundHDAccounts(t *testing.T) {
+	wallet, err := hdwallet.NewFromMnemonic("test test test test test test test test test test test junk", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addrs, err := wallet.Addresses(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &Genesis{Config: params.DefaultChainConfig, GasLimit: 8000000}
+	g.Fund(big.NewInt(1000), addrs...)
+
+	db := kvstore.NewMemoryKVStore()
+	block, err := SetupGenesisBlock(db, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := statedb.New(block.StateRoot, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range addrs {
+		if got := state.GetBalance(addr); got.Int64() != 1000 {
+			t.Fatalf("balance of %s = %v, want 1000", addr, got)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package BlockChain
+
+import (
+	"CHAIN/common"
+	"CHAIN/params"
+	"CHAIN/statedb"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	// ErrInvalidSender 表示无法从交易签名中恢复发送者
+	ErrInvalidSender = errors.New("invalid sender")
+	// ErrNonceMismatch 表示交易 nonce 不是发送者的下一个 nonce
+	ErrNonceMismatch = errors.New("nonce mismatch")
+	// ErrInsufficientFunds 表示发送者余额不足以支付 Gas 费用与转账金额
+	ErrInsufficientFunds = errors.New("insufficient funds for gas * price + value")
+	// ErrContractCreation 表示暂不支持的合约创建交易（To 为空）
+	ErrContractCreation = errors.New("contract creation not supported")
+	// ErrIntrinsicGas 表示交易的 Gas 上限低于固有 Gas
+	ErrIntrinsicGas = errors.New("intrinsic gas too low")
+	// ErrTipAboveFeeCap 表示交易的小费上限高于总价上限
+	ErrTipAboveFeeCap = errors.New("max priority fee per gas higher than max fee per gas")
+)
+
+// IntrinsicGas 返回执行一笔交易前必须支付的固有 Gas：
+// 基础 Gas 加上数据 Gas，以及访问列表中每个地址和存储槽的 Gas
+func IntrinsicGas(data []byte, accessList common.AccessList) uint64 {
+	gas := params.TxGas
+	for _, b := range data {
+		if b == 0 {
+			gas += params.TxDataZeroGas
+		} else {
+			gas += params.TxDataNonZeroGas
+		}
+	}
+	for _, tuple := range accessList {
+		gas += params.TxAccessListAddressGas
+		gas += uint64(len(tuple.StorageKeys)) * params.TxAccessListStorageKeyGas
+	}
+	return gas
+}
+
+// ApplyTransaction 在 state 上执行一笔转账交易，返回消耗的 Gas。
+// 账户 nonce 记录已执行的交易数，交易 nonce 必须等于账户 nonce+1。
+// 发送者按有效 Gas 价格支付费用：基础费用部分被销毁，小费部分付给 block.Coinbase。
+// 执行失败时 state 回滚到执行前的状态。
+func ApplyTransaction(state statedb.StateDB, signer common.Signer, block *Block, tx *common.Transaction) (uint64, error) {
+	// 负数字段无法编码，必须在恢复签名之前拒绝
+	if err := tx.ValidateValues(); err != nil {
+		return 0, err
+	}
+	from, err := common.Sender(signer, tx)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidSender, err)
+	}
+	if tx.To == nil {
+		return 0, ErrContractCreation
+	}
+	if nonce := state.GetNonce(from); tx.Nonce != nonce+1 {
+		return 0, fmt.Errorf("%w: address %s, tx nonce %d, state nonce %d", ErrNonceMismatch, from, tx.Nonce, nonce)
+	}
+	gas := IntrinsicGas(tx.Input, tx.AccessList)
+	if tx.GasLimit < gas {
+		return 0, fmt.Errorf("%w: have %d, want %d", ErrIntrinsicGas, tx.GasLimit, gas)
+	}
+	if tx.TipCap().Cmp(tx.FeeCap()) > 0 {
+		return 0, fmt.Errorf("%w: tip %v, fee cap %v", ErrTipAboveFeeCap, tx.TipCap(), tx.FeeCap())
+	}
+	tip, err := tx.EffectiveGasTip(block.BaseFee)
+	if err != nil {
+		return 0, fmt.Errorf("%w: address %s, fee cap %v, base fee %v", err, from, tx.FeeCap(), block.BaseFee)
+	}
+
+	// 余额必须覆盖按 Gas 上限和最高价格计算的费用以及转账金额
+	value := tx.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	maxCost := tx.Cost()
+	if balance := state.GetBalance(from); balance.Cmp(maxCost) < 0 {
+		return 0, fmt.Errorf("%w: address %s have %v want %v", ErrInsufficientFunds, from, balance, maxCost)
+	}
+
+	gasUsed := new(big.Int).SetUint64(gas)
+	fee := new(big.Int).Mul(gasUsed, tx.EffectiveGasPrice(block.BaseFee))
+	snap := state.Snapshot()
+	if err := state.SubBalance(from, new(big.Int).Add(fee, value)); err != nil {
+		state.RevertToSnapshot(snap)
+		return 0, err
+	}
+	state.AddBalance(*tx.To, value)
+	// 基础费用部分不记入任何账户，即被销毁
+	if tip.Sign() > 0 {
+		state.AddBalance(block.Coinbase, tip.Mul(tip, gasUsed))
+	}
+	state.SetNonce(from, tx.Nonce)
+	return gas, nil
+}
@@ -1,45 +1,64 @@
 package common
 
 import (
-	"encoding/json"
 	"math/big"
 	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
-// Account 表示区块链上的一个账户
+// EmptyCodeHash 是空代码的 keccak256 哈希，即没有代码的账户的 CodeHash
+var EmptyCodeHash = BytesToHash(crypto.Keccak256(nil))
+
+// Account 表示区块链上的一个账户。
+// 状态树中只保存 Nonce、Balance、Root、CodeHash 四个字段，
+// 代码按哈希单独存储，合约存储保存在以 Root 为根的存储树中；
+// Code 与 Storage 只是状态数据库在内存中的缓存
 type Account struct {
-	Address Address           // 账户地址
-	Balance *big.Int          // 账户余额
-	Nonce   uint64            // 发送过的交易数量，用于防止重放
-	Code    []byte            // 合约账户代码（EOA则为nil）
-	Storage map[string]string // 合约存储（可选，做MPT时常用）
+	Address  Address           // 账户地址，即状态树中的键，不参与编码
+	Balance  *big.Int          // 账户余额
+	Nonce    uint64            // 发送过的交易数量，用于防止重放
+	Root     Hash              // 存储树的根哈希，空哈希表示没有存储
+	CodeHash Hash              // 合约代码的哈希，EOA 为 EmptyCodeHash
+	Code     []byte            // 合约账户代码（EOA则为nil），按需从数据库加载
+	Storage  map[string]string // 已加载或修改过的存储槽
 
 	lock sync.RWMutex // 并发读写保护
 }
 
+// accountRLP 是账户在状态树中的规范编码：[nonce, balance, storageRoot, codeHash]
+type accountRLP struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     Hash
+	CodeHash Hash
+}
+
 // NewAccount 创建一个新账户
 func NewAccount(address Address) *Account {
 	return &Account{
-		Address: address,
-		Balance: big.NewInt(0),
-		Nonce:   0,
-		Code:    nil,
-		Storage: make(map[string]string),
+		Address:  address,
+		Balance:  big.NewInt(0),
+		Nonce:    0,
+		CodeHash: EmptyCodeHash,
+		Code:     nil,
+		Storage:  make(map[string]string),
 	}
 }
 
-// AddBalance 增加余额
+// AddBalance 增加余额，Balance 为 nil 时视为 0
 func (a *Account) AddBalance(amount *big.Int) {
 	a.lock.Lock()
 	defer a.lock.Unlock()
-	a.Balance.Add(a.Balance, amount)
+	a.Balance = new(big.Int).Add(bigOrZero(a.Balance), amount)
 }
 
-// SubBalance 扣减余额（不检查余额是否足够）
+// SubBalance 扣减余额（不检查余额是否足够），Balance 为 nil 时视为 0
 func (a *Account) SubBalance(amount *big.Int) {
 	a.lock.Lock()
 	defer a.lock.Unlock()
-	a.Balance.Sub(a.Balance, amount)
+	a.Balance = new(big.Int).Sub(bigOrZero(a.Balance), amount)
 }
 
 // SetNonce 设置账户的nonce
@@ -56,51 +75,51 @@ func (a *Account) GetNonce() uint64 {
 	return a.Nonce
 }
 
-// SetCode 设置合约代码
+// SetCode 设置合约代码并更新代码哈希
 func (a *Account) SetCode(code []byte) {
 	a.lock.Lock()
 	defer a.lock.Unlock()
 	a.Code = code
+	a.CodeHash = BytesToHash(crypto.Keccak256(code))
 }
 
 // IsContract 判断账户是否是合约账户
 func (a *Account) IsContract() bool {
 	a.lock.RLock()
 	defer a.lock.RUnlock()
-	return len(a.Code) > 0
+	return a.CodeHash != EmptyCodeHash && !a.CodeHash.IsEmpty()
 }
 
-// BytesToAccount 将字节数据反序列化为Account对象
+// BytesToAccount 从状态树中的 RLP 编码解码账户，Address、Code 需由调用方补全
 func BytesToAccount(data []byte) (*Account, error) {
-	var account Account
-	err := json.Unmarshal(data, &account)
-	if err != nil {
+	var dec accountRLP
+	if err := rlp.DecodeBytes(data, &dec); err != nil {
 		return nil, err
 	}
-	return &account, nil
+	return &Account{
+		Balance:  dec.Balance,
+		Nonce:    dec.Nonce,
+		Root:     dec.Root,
+		CodeHash: dec.CodeHash,
+		Storage:  make(map[string]string),
+	}, nil
 }
 
-// Bytes 将Account对象序列化为字节数组
+// Bytes 返回账户在状态树中的 RLP 编码，不包含地址、代码和存储内容
 func (a *Account) Bytes() ([]byte, error) {
 	a.lock.RLock()
 	defer a.lock.RUnlock()
-
-	// 使用结构体拷贝避免竞态条件
-	accountCopy := struct {
-		Address Address           `json:"address"`
-		Balance *big.Int          `json:"balance"`
-		Nonce   uint64            `json:"nonce"`
-		Code    []byte            `json:"code,omitempty"`
-		Storage map[string]string `json:"storage,omitempty"`
-	}{
-		Address: a.Address,
-		Balance: new(big.Int).Set(a.Balance), // 深拷贝Balance
-		Nonce:   a.Nonce,
-		Code:    a.Code,
-		Storage: a.Storage,
+	codeHash := a.CodeHash
+	if codeHash.IsEmpty() {
+		// 未经 NewAccount 创建的账户没有设置代码哈希
+		codeHash = EmptyCodeHash
 	}
-
-	return json.Marshal(accountCopy)
+	return rlp.EncodeToBytes(&accountRLP{
+		Nonce:    a.Nonce,
+		Balance:  bigOrZero(a.Balance),
+		Root:     a.Root,
+		CodeHash: codeHash,
+	})
 }
 
 func (a *Account) Lock() {
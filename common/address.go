@@ -2,39 +2,126 @@ package common
 
 import (
 	"encoding/hex"
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/crypto/secp256k1"
+	"errors"
+	"fmt"
 	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
-type Address [20]byte
+// AddressLength 是地址的字节长度
+const AddressLength = 20
 
-func (a Address) String() string {
-	return "0x" + hex.EncodeToString(a[:])
+var (
+	// ErrInvalidAddress 表示地址不是 40 位十六进制字符串
+	ErrInvalidAddress = errors.New("invalid address")
+	// ErrInvalidChecksum 表示大小写混合的地址不符合 EIP-55 校验
+	ErrInvalidChecksum = errors.New("invalid address checksum")
+)
+
+type Address [AddressLength]byte
+
+// BytesToAddress 将字节切片转换为地址，超长时保留末尾 20 字节
+func BytesToAddress(b []byte) Address {
+	var a Address
+	if len(b) > len(a) {
+		b = b[len(b)-AddressLength:]
+	}
+	copy(a[AddressLength-len(b):], b)
+	return a
 }
 
-func RecoverAddress(hash []byte, r, s *big.Int, v byte) Address {
-	// 构建 65 字节的签名 (r || s || v)
-	sig := make([]byte, 65)
-	copy(sig[32-len(r.Bytes()):32], r.Bytes())
-	copy(sig[64-len(s.Bytes()):64], s.Bytes())
-	sig[64] = v - 27 // v should be 0 or 1 for recovery in secp256k1
+// HexToAddress 把十六进制字符串转换为地址，不做校验，适用于常量；
+// 解析外部输入应使用 ParseAddress
+func HexToAddress(s string) Address {
+	return BytesToAddress(fromHex(s))
+}
 
-	pubKeyBytes, err := secp256k1.RecoverPubkey(hash, sig)
+// ParseAddress 严格解析十六进制地址：0x 前缀可选，必须恰好 40 位十六进制字符；
+// 全小写或全大写时不校验，大小写混合时必须符合 EIP-55 校验
+func ParseAddress(s string) (Address, error) {
+	raw := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(raw) != 2*AddressLength {
+		return Address{}, fmt.Errorf("%w: %q has length %d, want %d", ErrInvalidAddress, s, len(raw), 2*AddressLength)
+	}
+	b, err := hex.DecodeString(raw)
 	if err != nil {
-		panic(err) // 你可以更优雅地处理错误
+		return Address{}, fmt.Errorf("%w: %q", ErrInvalidAddress, s)
+	}
+	addr := BytesToAddress(b)
+	if raw != strings.ToLower(raw) && raw != strings.ToUpper(raw) && addr.Hex()[2:] != raw {
+		return Address{}, fmt.Errorf("%w: %q", ErrInvalidChecksum, s)
+	}
+	return addr, nil
+}
+
+// IsHexAddress 判断 s 是否为合法的十六进制地址（含 EIP-55 校验）
+func IsHexAddress(s string) bool {
+	_, err := ParseAddress(s)
+	return err == nil
+}
+
+// Bytes 转换为字节切片
+func (a Address) Bytes() []byte {
+	return a[:]
+}
+
+// Hex 返回带 0x 前缀、符合 EIP-55 大小写校验的十六进制表示
+func (a Address) Hex() string {
+	buf := []byte(hex.EncodeToString(a[:]))
+	hash := crypto.Keccak256(buf)
+	// 哈希对应半字节 >= 8 时该位字母大写
+	for i := range buf {
+		if buf[i] < 'a' {
+			continue
+		}
+		nibble := hash[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if nibble&0xf >= 8 {
+			buf[i] -= 'a' - 'A'
+		}
 	}
+	return "0x" + string(buf)
+}
+
+// String 实现 Stringer 接口，返回 EIP-55 校验格式
+func (a Address) String() string {
+	return a.Hex()
+}
+
+// MarshalText 实现 encoding.TextMarshaler，JSON 中编码为校验格式的字符串
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.Hex()), nil
+}
 
-	pubKey, err := crypto.UnmarshalPubkey(pubKeyBytes)
+// UnmarshalText 实现 encoding.TextUnmarshaler，按 ParseAddress 严格解析
+func (a *Address) UnmarshalText(input []byte) error {
+	addr, err := ParseAddress(string(input))
 	if err != nil {
-		panic(err)
+		return err
 	}
+	*a = addr
+	return nil
+}
 
-	// 计算地址：Keccak256(pubkey[1:])[12:]
-	pubBytes := crypto.FromECDSAPub(pubKey)[1:] // 去掉前缀 0x04
-	addressBytes := crypto.Keccak256(pubBytes)[12:]
+// RecoverAddress 从签名哈希和 r、s、v（27/28）恢复签名者地址，
+// 签名无效时返回 ErrInvalidSig
+func RecoverAddress(hash []byte, r, s *big.Int, v byte) (Address, error) {
+	if r == nil || s == nil || (v != 27 && v != 28) {
+		return Address{}, ErrInvalidSig
+	}
+	return recoverSender(hash, r, s, v-27)
+}
 
-	var addr Address
-	copy(addr[:], addressBytes)
-	return addr
+// fromHex 解码可带 0x 前缀的十六进制字符串，奇数长度时在前面补 0，非法输入返回 nil
+func fromHex(s string) []byte {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	b, _ := hex.DecodeString(s)
+	return b
 }
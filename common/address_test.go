@@ -0,0 +1,125 @@
+package common_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"CHAIN/common"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// EIP-55 中给出的测试向量
+var checksumVectors = []string{
+	"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+	"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+	"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+	"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+}
+
+func TestAddressChecksum(t *testing.T) {
+	for _, want := range checksumVectors {
+		addr := common.HexToAddress(strings.ToLower(want))
+		if got := addr.Hex(); got != want {
+			t.Errorf("Hex() = %s, want %s", got, want)
+		}
+		if got := ethcommon.Address(addr).Hex(); got != want {
+			t.Errorf("go-ethereum checksum = %s, want %s", got, want)
+		}
+		parsed, err := common.ParseAddress(want)
+		if err != nil || parsed != addr {
+			t.Errorf("ParseAddress(%s) = %s, %v", want, parsed, err)
+		}
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	valid := []string{
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		"0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED",
+		"5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+	}
+	for _, s := range valid {
+		if !common.IsHexAddress(s) {
+			t.Errorf("IsHexAddress(%q) = false", s)
+		}
+	}
+
+	invalid := map[string]error{
+		"":       common.ErrInvalidAddress,
+		"0x":     common.ErrInvalidAddress,
+		"0x1234": common.ErrInvalidAddress,
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed00": common.ErrInvalidAddress,
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaeg":   common.ErrInvalidAddress,
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD":   common.ErrInvalidChecksum,
+		"0X5aaeb6053f3e94c9b9a09f33669435e7ef1beaed ":  common.ErrInvalidAddress,
+		"0x0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed": common.ErrInvalidAddress,
+	}
+	for s, want := range invalid {
+		if _, err := common.ParseAddress(s); !errors.Is(err, want) {
+			t.Errorf("ParseAddress(%q) = %v, want %v", s, err, want)
+		}
+	}
+}
+
+func TestHexToAddress(t *testing.T) {
+	want := common.Address{19: 0x01}
+	for _, s := range []string{"0x1", "01", "0x0000000000000000000000000000000000000001"} {
+		if got := common.HexToAddress(s); got != want {
+			t.Errorf("HexToAddress(%q) = %s", s, got)
+		}
+	}
+}
+
+func TestParseHash(t *testing.T) {
+	s := "0x00000000000000000000000000000000000000000000000000000000000000ff"
+	h, err := common.ParseHash(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h != common.HexToHash("0xff") || h.String() != s || h.Hex() != s {
+		t.Fatalf("ParseHash(%s) = %s", s, h)
+	}
+	for _, bad := range []string{"", "0xff", s + "00", s[:len(s)-1] + "g"} {
+		if _, err := common.ParseHash(bad); !errors.Is(err, common.ErrInvalidHash) {
+			t.Errorf("ParseHash(%q) = %v, want ErrInvalidHash", bad, err)
+		}
+	}
+}
+
+func TestAddressHashJSON(t *testing.T) {
+	type payload struct {
+		Addr    common.Address
+		Hash    common.Hash
+		Balance map[common.Address]int
+	}
+	addr := common.HexToAddress(checksumVectors[0])
+	in := payload{
+		Addr:    addr,
+		Hash:    common.HexToHash("0xabcdef"),
+		Balance: map[common.Address]int{addr: 1},
+	}
+	enc, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"Addr":"` + checksumVectors[0] + `","Hash":"0x0000000000000000000000000000000000000000000000000000000000abcdef","Balance":{"` + checksumVectors[0] + `":1}}`
+	if string(enc) != want {
+		t.Fatalf("json = %s\nwant %s", enc, want)
+	}
+	var out payload
+	if err := json.Unmarshal(enc, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Addr != in.Addr || out.Hash != in.Hash || out.Balance[addr] != 1 {
+		t.Fatalf("round trip = %+v, want %+v", out, in)
+	}
+
+	if err := json.Unmarshal([]byte(`{"Addr":"0x1234"}`), &out); !errors.Is(err, common.ErrInvalidAddress) {
+		t.Errorf("unmarshal short address = %v, want ErrInvalidAddress", err)
+	}
+	if err := json.Unmarshal([]byte(`{"Hash":"0x1234"}`), &out); !errors.Is(err, common.ErrInvalidHash) {
+		t.Errorf("unmarshal short hash = %v, want ErrInvalidHash", err)
+	}
+}
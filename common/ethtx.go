@@ -0,0 +1,134 @@
+package common
+
+import (
+	"fmt"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// FromEthTransaction 把 go-ethereum 的交易转换为本地交易，
+// 保留签名，转换后的交易哈希与原交易一致。
+// 支持 legacy、访问列表与 EIP-1559 交易，其他类型返回 ErrTxTypeNotSupported
+func FromEthTransaction(ethTx *types.Transaction) (*Transaction, error) {
+	v, r, s := ethTx.RawSignatureValues()
+	tx := &Transaction{
+		Nonce:    ethTx.Nonce(),
+		GasLimit: ethTx.Gas(),
+		Value:    ethTx.Value(),
+		Input:    ethTx.Data(),
+		V:        v,
+		R:        r,
+		S:        s,
+	}
+	if to := ethTx.To(); to != nil {
+		addr := Address(*to)
+		tx.To = &addr
+	}
+	switch ethTx.Type() {
+	case types.LegacyTxType:
+		tx.Type = LegacyTxType
+		tx.GasPrice = ethTx.GasPrice()
+		if tx.Protected() {
+			tx.ChainID = deriveChainID(tx.V)
+		}
+	case types.AccessListTxType:
+		tx.Type = AccessListTxType
+		tx.ChainID = ethTx.ChainId()
+		tx.GasPrice = ethTx.GasPrice()
+		tx.AccessList = fromEthAccessList(ethTx.AccessList())
+	case types.DynamicFeeTxType:
+		tx.Type = DynamicFeeTxType
+		tx.ChainID = ethTx.ChainId()
+		tx.GasTipCap = ethTx.GasTipCap()
+		tx.GasFeeCap = ethTx.GasFeeCap()
+		tx.AccessList = fromEthAccessList(ethTx.AccessList())
+	default:
+		return nil, fmt.Errorf("%w: type %d", ErrTxTypeNotSupported, ethTx.Type())
+	}
+	return tx, nil
+}
+
+// ToEthTransaction 把本地交易转换为 go-ethereum 的交易，保留签名
+func (tx *Transaction) ToEthTransaction() (*types.Transaction, error) {
+	var to *ethcommon.Address
+	if tx.To != nil {
+		addr := ethcommon.Address(*tx.To)
+		to = &addr
+	}
+	switch tx.Type {
+	case LegacyTxType:
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    tx.Nonce,
+			GasPrice: bigOrZero(tx.GasPrice),
+			Gas:      tx.GasLimit,
+			To:       to,
+			Value:    bigOrZero(tx.Value),
+			Data:     tx.Input,
+			V:        bigOrZero(tx.V),
+			R:        bigOrZero(tx.R),
+			S:        bigOrZero(tx.S),
+		}), nil
+	case AccessListTxType:
+		return types.NewTx(&types.AccessListTx{
+			ChainID:    bigOrZero(tx.ChainID),
+			Nonce:      tx.Nonce,
+			GasPrice:   bigOrZero(tx.GasPrice),
+			Gas:        tx.GasLimit,
+			To:         to,
+			Value:      bigOrZero(tx.Value),
+			Data:       tx.Input,
+			AccessList: toEthAccessList(tx.AccessList),
+			V:          bigOrZero(tx.V),
+			R:          bigOrZero(tx.R),
+			S:          bigOrZero(tx.S),
+		}), nil
+	case DynamicFeeTxType:
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    bigOrZero(tx.ChainID),
+			Nonce:      tx.Nonce,
+			GasTipCap:  bigOrZero(tx.GasTipCap),
+			GasFeeCap:  bigOrZero(tx.GasFeeCap),
+			Gas:        tx.GasLimit,
+			To:         to,
+			Value:      bigOrZero(tx.Value),
+			Data:       tx.Input,
+			AccessList: toEthAccessList(tx.AccessList),
+			V:          bigOrZero(tx.V),
+			R:          bigOrZero(tx.R),
+			S:          bigOrZero(tx.S),
+		}), nil
+	default:
+		return nil, fmt.Errorf("%w: type %d", ErrTxTypeNotSupported, tx.Type)
+	}
+}
+
+func fromEthAccessList(list types.AccessList) AccessList {
+	if list == nil {
+		return nil
+	}
+	out := make(AccessList, len(list))
+	for i, tuple := range list {
+		out[i].Address = Address(tuple.Address)
+		out[i].StorageKeys = make([]Hash, len(tuple.StorageKeys))
+		for j, key := range tuple.StorageKeys {
+			out[i].StorageKeys[j] = Hash(key)
+		}
+	}
+	return out
+}
+
+func toEthAccessList(list AccessList) types.AccessList {
+	if list == nil {
+		return nil
+	}
+	out := make(types.AccessList, len(list))
+	for i, tuple := range list {
+		out[i].Address = ethcommon.Address(tuple.Address)
+		out[i].StorageKeys = make([]ethcommon.Hash, len(tuple.StorageKeys))
+		for j, key := range tuple.StorageKeys {
+			out[i].StorageKeys[j] = ethcommon.Hash(key)
+		}
+	}
+	return out
+}
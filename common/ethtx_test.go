@@ -0,0 +1,179 @@
+package common_test
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"CHAIN/common"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestEthTransactionConversion(t *testing.T) {
+	key, _ := ethcrypto.GenerateKey()
+	chainID := big.NewInt(1337)
+	ethSigner := types.NewLondonSigner(chainID)
+	to := ethcommon.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	txs := map[string]types.TxData{
+		"legacy": &types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(7), Gas: 21000, To: &to, Value: big.NewInt(5)},
+		"access list": &types.AccessListTx{
+			ChainID: chainID, Nonce: 4, GasPrice: big.NewInt(8), Gas: 30000, To: &to, Value: big.NewInt(3),
+			AccessList: types.AccessList{{Address: to, StorageKeys: []ethcommon.Hash{{7}}}},
+		},
+		"dynamic fee": &types.DynamicFeeTx{
+			ChainID: chainID, Nonce: 2, GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(30), Gas: 50000,
+			To: &to, Value: big.NewInt(9), Data: []byte{0xde, 0xad},
+			AccessList: types.AccessList{{Address: to, StorageKeys: []ethcommon.Hash{{1}, {2}}}},
+		},
+		"contract creation": &types.DynamicFeeTx{ChainID: chainID, Nonce: 3, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1), Gas: 90000, Data: []byte{1}},
+	}
+	for name, data := range txs {
+		ethTx, err := types.SignNewTx(key, ethSigner, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx, err := common.FromEthTransaction(ethTx)
+		if err != nil {
+			t.Fatalf("%s: FromEthTransaction: %v", name, err)
+		}
+		if !bytes.Equal(mustHash(t, tx), ethTx.Hash().Bytes()) {
+			t.Fatalf("%s: hash changed by conversion", name)
+		}
+		wantEnc, _ := ethTx.MarshalBinary()
+		if enc, _ := tx.MarshalBinary(); !bytes.Equal(enc, wantEnc) {
+			t.Fatalf("%s: encoding differs from go-ethereum", name)
+		}
+
+		// 以太坊签名的交易可以直接恢复出发送者
+		from, err := common.Sender(common.NewLondonSigner(chainID), tx)
+		if err != nil || from != common.Address(ethcrypto.PubkeyToAddress(key.PublicKey)) {
+			t.Fatalf("%s: Sender = %x, %v", name, from, err)
+		}
+
+		back, err := tx.ToEthTransaction()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if back.Hash() != ethTx.Hash() {
+			t.Fatalf("%s: hash changed by round trip", name)
+		}
+
+		var decoded common.Transaction
+		if err := decoded.UnmarshalBinary(wantEnc); err != nil {
+			t.Fatalf("%s: UnmarshalBinary: %v", name, err)
+		}
+		if !bytes.Equal(mustHash(t, &decoded), ethTx.Hash().Bytes()) {
+			t.Fatalf("%s: decoded hash differs", name)
+		}
+	}
+
+	blobTx := types.NewTx(&types.BlobTx{Nonce: 1, Gas: 21000})
+	if _, err := common.FromEthTransaction(blobTx); !errors.Is(err, common.ErrTxTypeNotSupported) {
+		t.Fatalf("FromEthTransaction(blob tx) = %v, want ErrTxTypeNotSupported", err)
+	}
+}
+
+func TestLondonSigner(t *testing.T) {
+	key, _ := ethcrypto.GenerateKey()
+	to := common.Address{1}
+	tx := &common.Transaction{
+		Type: common.DynamicFeeTxType, Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(10),
+		GasLimit: 21000, To: &to, Value: big.NewInt(1),
+	}
+	signer := common.NewLondonSigner(big.NewInt(1337))
+	if _, err := signer.SignTx(tx, key); err != nil {
+		t.Fatal(err)
+	}
+	if tx.ChainID.Int64() != 1337 || tx.V.Uint64() > 1 {
+		t.Fatalf("unexpected signature values chainID=%v V=%v", tx.ChainID, tx.V)
+	}
+	if from, err := tx.From(); err != nil || from != common.Address(ethcrypto.PubkeyToAddress(key.PublicKey)) {
+		t.Fatalf("From() = %x, %v", from, err)
+	}
+
+	// 旧签名器不认识 EIP-1559 交易
+	if _, err := common.NewEIP155Signer(big.NewInt(1337)).Sender(tx); !errors.Is(err, common.ErrTxTypeNotSupported) {
+		t.Fatalf("EIP155Signer.Sender = %v, want ErrTxTypeNotSupported", err)
+	}
+	if _, err := common.NewLondonSigner(big.NewInt(1)).Sender(tx); !errors.Is(err, common.ErrInvalidChainID) {
+		t.Fatalf("Sender on other chain = %v, want ErrInvalidChainID", err)
+	}
+}
+
+func TestBerlinSigner(t *testing.T) {
+	key, _ := ethcrypto.GenerateKey()
+	to := common.Address{1}
+	tx := &common.Transaction{
+		Type: common.AccessListTxType, Nonce: 1, GasPrice: big.NewInt(10), GasLimit: 30000, To: &to,
+		AccessList: common.AccessList{{Address: to, StorageKeys: []common.Hash{{1}}}},
+	}
+	signer := common.NewBerlinSigner(big.NewInt(1337))
+	if _, err := signer.SignTx(tx, key); err != nil {
+		t.Fatal(err)
+	}
+	want := common.Address(ethcrypto.PubkeyToAddress(key.PublicKey))
+	for _, s := range []common.Signer{signer, common.NewLondonSigner(big.NewInt(1337))} {
+		if from, err := s.Sender(tx); err != nil || from != want {
+			t.Fatalf("%T.Sender = %x, %v", s, from, err)
+		}
+	}
+
+	// 修改访问列表会使签名失效
+	tx.AccessList[0].StorageKeys[0] = common.Hash{2}
+	if from, err := signer.Sender(tx); err == nil && from == want {
+		t.Fatal("access list is not covered by the signature")
+	}
+
+	// Berlin 签名器不认识 EIP-1559 交易
+	dynamic := &common.Transaction{Type: common.DynamicFeeTxType, GasFeeCap: big.NewInt(1), GasTipCap: big.NewInt(1)}
+	if _, err := signer.SignTx(dynamic, key); !errors.Is(err, common.ErrTxTypeNotSupported) {
+		t.Fatalf("BerlinSigner.SignTx(dynamic fee) = %v, want ErrTxTypeNotSupported", err)
+	}
+}
+
+// 不同类型的交易可以放在同一个 RLP 列表中（即区块体的交易列表）编解码
+func TestTypedTransactionList(t *testing.T) {
+	key, _ := ethcrypto.GenerateKey()
+	signer := common.NewLondonSigner(big.NewInt(1337))
+	to := common.Address{1}
+	txs := []*common.Transaction{
+		{Nonce: 1, GasPrice: big.NewInt(1), GasLimit: 21000, To: &to},
+		{Type: common.AccessListTxType, Nonce: 2, GasPrice: big.NewInt(1), GasLimit: 30000, To: &to,
+			AccessList: common.AccessList{{Address: to}}},
+		{Type: common.DynamicFeeTxType, Nonce: 3, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), GasLimit: 21000},
+	}
+	for _, tx := range txs {
+		if _, err := signer.SignTx(tx, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	enc, err := rlp.EncodeToBytes(txs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []*common.Transaction
+	if err := rlp.DecodeBytes(enc, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != len(txs) {
+		t.Fatalf("decoded %d transactions, want %d", len(decoded), len(txs))
+	}
+	for i := range txs {
+		if decoded[i].Type != txs[i].Type || !bytes.Equal(mustHash(t, decoded[i]), mustHash(t, txs[i])) {
+			t.Fatalf("tx %d changed by round trip", i)
+		}
+	}
+
+	var tx common.Transaction
+	if err := tx.UnmarshalBinary([]byte{0x05, 0xc0}); !errors.Is(err, common.ErrTxTypeNotSupported) {
+		t.Fatalf("UnmarshalBinary(unknown type) = %v, want ErrTxTypeNotSupported", err)
+	}
+	if _, err := (&common.Transaction{Type: 0x05}).MarshalBinary(); !errors.Is(err, common.ErrTxTypeNotSupported) {
+		t.Fatalf("MarshalBinary(unknown type) = %v, want ErrTxTypeNotSupported", err)
+	}
+}
@@ -1,14 +1,24 @@
 package common
 
 import (
-	"bytes"
-	"crypto/sha256"
-	"encoding/binary"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
+// HashLength 是哈希的字节长度
+const HashLength = 32
+
+// ErrInvalidHash 表示哈希不是 64 位十六进制字符串
+var ErrInvalidHash = errors.New("invalid hash")
+
 // Hash 表示一个32字节的哈希值
-type Hash [32]byte
+type Hash [HashLength]byte
 
 // BytesToHash 将字节切片转换为Hash
 func BytesToHash(b []byte) Hash {
@@ -17,35 +27,126 @@ func BytesToHash(b []byte) Hash {
 	return h
 }
 
-// String 实现Stringer接口
+// HexToHash 把十六进制字符串转换为哈希，不做校验，适用于常量；
+// 解析外部输入应使用 ParseHash
+func HexToHash(s string) Hash {
+	b := fromHex(s)
+	if len(b) > HashLength {
+		b = b[len(b)-HashLength:]
+	}
+	var h Hash
+	copy(h[HashLength-len(b):], b)
+	return h
+}
+
+// ParseHash 严格解析十六进制哈希：0x 前缀可选，必须恰好 64 位十六进制字符
+func ParseHash(s string) (Hash, error) {
+	raw := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(raw) != 2*HashLength {
+		return Hash{}, fmt.Errorf("%w: %q has length %d, want %d", ErrInvalidHash, s, len(raw), 2*HashLength)
+	}
+	b, err := hex.DecodeString(raw)
+	if err != nil {
+		return Hash{}, fmt.Errorf("%w: %q", ErrInvalidHash, s)
+	}
+	return BytesToHash(b), nil
+}
+
+// String 实现Stringer接口，返回带 0x 前缀的十六进制表示
 func (h Hash) String() string {
-	return string(h[:])
+	return h.Hex()
 }
 
-func (tx *Transaction) Hash() []byte {
-	buf := new(bytes.Buffer)
+// MarshalText 实现 encoding.TextMarshaler，JSON 中编码为十六进制字符串
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.Hex()), nil
+}
 
-	binary.Write(buf, binary.BigEndian, tx.Nonce)
-	binary.Write(buf, binary.BigEndian, tx.GasPrice)
-	binary.Write(buf, binary.BigEndian, tx.GasLimit)
+// UnmarshalText 实现 encoding.TextUnmarshaler，按 ParseHash 严格解析
+func (h *Hash) UnmarshalText(input []byte) error {
+	hash, err := ParseHash(string(input))
+	if err != nil {
+		return err
+	}
+	*h = hash
+	return nil
+}
 
-	if tx.To != nil {
-		buf.Write(tx.To[:])
-	} else {
-		buf.Write(make([]byte, 20)) // Empty address
+// Hash 返回交易 ID：对包含签名在内的完整规范编码做 keccak256，
+// 因此签名不同的两笔交易不会得到相同的 ID。
+// 未知类型或数值字段为负数的交易无法编码，返回错误
+func (tx *Transaction) Hash() ([]byte, error) {
+	enc, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
 	}
+	return crypto.Keccak256(enc), nil
+}
 
-	// 判断 tx.Value 是否为 nil
-	if tx.Value != nil {
-		buf.Write(tx.Value.Bytes())
-	} else {
-		buf.Write([]byte{}) // 空
+// signingFields 返回参与签名的交易字段（不含签名），
+// 顺序与以太坊 legacy 交易一致：nonce, gasPrice, gas, to, value, data
+func (tx *Transaction) signingFields() []interface{} {
+	return []interface{}{
+		tx.Nonce,
+		bigOrZero(tx.GasPrice),
+		tx.GasLimit,
+		tx.To,
+		bigOrZero(tx.Value),
+		tx.Input,
 	}
+}
 
-	buf.Write(tx.Input)
+// typedSigningHash 返回类型化交易的签名哈希：keccak256(type || rlp(不含签名的负载字段))
+//
+//	0x01: [chainId, nonce, gasPrice, gas, to, value, data, accessList]
+//	0x02: [chainId, nonce, gasTipCap, gasFeeCap, gas, to, value, data, accessList]
+func (tx *Transaction) typedSigningHash(chainID *big.Int) ([]byte, error) {
+	switch tx.Type {
+	case AccessListTxType:
+		return prefixedRlpHash(tx.Type, []interface{}{
+			chainID,
+			tx.Nonce,
+			bigOrZero(tx.GasPrice),
+			tx.GasLimit,
+			tx.To,
+			bigOrZero(tx.Value),
+			tx.Input,
+			tx.AccessList,
+		})
+	case DynamicFeeTxType:
+		return prefixedRlpHash(tx.Type, []interface{}{
+			chainID,
+			tx.Nonce,
+			bigOrZero(tx.GasTipCap),
+			bigOrZero(tx.GasFeeCap),
+			tx.GasLimit,
+			tx.To,
+			bigOrZero(tx.Value),
+			tx.Input,
+			tx.AccessList,
+		})
+	default:
+		return nil, ErrTxTypeNotSupported
+	}
+}
 
-	hash := sha256.Sum256(buf.Bytes())
-	return hash[:]
+// rlpHash 返回 x 的 RLP 编码的 keccak256 哈希。
+// 交易字段来自外部输入，负数的 big.Int 等无法编码时返回错误
+func rlpHash(x interface{}) ([]byte, error) {
+	enc, err := rlp.EncodeToBytes(x)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(enc), nil
+}
+
+// prefixedRlpHash 返回 prefix || rlp(x) 的 keccak256 哈希，无法编码时返回错误
+func prefixedRlpHash(prefix byte, x interface{}) ([]byte, error) {
+	enc, err := rlp.EncodeToBytes(x)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256([]byte{prefix}, enc), nil
 }
 
 // Bytes 转换为字节切片
@@ -53,9 +154,9 @@ func (h Hash) Bytes() []byte {
 	return h[:]
 }
 
-// Hex 返回16进制字符串表示
+// Hex 返回带 0x 前缀的16进制字符串表示
 func (h Hash) Hex() string {
-	return hex.EncodeToString(h[:])
+	return "0x" + hex.EncodeToString(h[:])
 }
 
 // IsEmpty 判断是否为空哈希
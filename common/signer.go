@@ -0,0 +1,352 @@
+package common
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"CHAIN/params"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/secp256k1"
+)
+
+var (
+	// ErrInvalidChainID 表示交易签名中的链 ID 与当前链不一致
+	ErrInvalidChainID = errors.New("invalid chain id for signer")
+	// ErrInvalidSig 表示签名值缺失、越界或不满足 low-S 规则
+	ErrInvalidSig = errors.New("invalid transaction v, r, s values")
+)
+
+var big35 = big.NewInt(35)
+
+// Signer 封装交易签名相关的规则，调用方不需要直接处理 R、S、V
+type Signer interface {
+	// SignatureHash 返回需要签名的交易哈希，交易字段无法编码时返回错误
+	SignatureHash(tx *Transaction) ([]byte, error)
+	// Sender 从签名中恢复发送者地址
+	Sender(tx *Transaction) (Address, error)
+	// SignTx 用私钥签名交易，写入 R、S、V 后返回同一笔交易
+	SignTx(tx *Transaction, key *ecdsa.PrivateKey) (*Transaction, error)
+	// ChainID 返回签名器的链 ID，不区分链时返回 nil
+	ChainID() *big.Int
+	// Equal 判断两个签名器的规则是否相同
+	Equal(Signer) bool
+}
+
+// MakeSigner 返回指定高度应使用的签名器
+func MakeSigner(config *params.ChainConfig, blockNumber uint64) Signer {
+	if config.IsLondon(blockNumber) {
+		return NewLondonSigner(config.ChainID)
+	}
+	if config.IsBerlin(blockNumber) {
+		return NewBerlinSigner(config.ChainID)
+	}
+	if config.IsEIP155(blockNumber) {
+		return NewEIP155Signer(config.ChainID)
+	}
+	return HomesteadSigner{}
+}
+
+// Sender 使用 signer 恢复交易发送者，结果缓存在交易上，
+// 同一签名器再次调用时直接返回缓存的地址
+func Sender(signer Signer, tx *Transaction) (Address, error) {
+	if sc := tx.from.Load(); sc != nil && sc.signer.Equal(signer) {
+		return sc.from, nil
+	}
+	addr, err := signer.Sender(tx)
+	if err != nil {
+		return Address{}, err
+	}
+	tx.from.Store(&sigCache{signer: signer, from: addr})
+	return addr, nil
+}
+
+// sigCache 缓存恢复出的发送者以及所用的签名器
+type sigCache struct {
+	signer Signer
+	from   Address
+}
+
+// HomesteadSigner 是不带重放保护的签名器，V 为 27/28
+type HomesteadSigner struct{}
+
+func (s HomesteadSigner) ChainID() *big.Int { return nil }
+
+func (s HomesteadSigner) Equal(other Signer) bool {
+	_, ok := other.(HomesteadSigner)
+	return ok
+}
+
+// SignatureHash 返回交易的签名哈希：keccak256(rlp(nonce, gasPrice, gas, to, value, data))
+func (s HomesteadSigner) SignatureHash(tx *Transaction) ([]byte, error) {
+	return rlpHash(tx.signingFields())
+}
+
+// Sender 从签名中恢复发送者地址
+func (s HomesteadSigner) Sender(tx *Transaction) (Address, error) {
+	if tx.Type != LegacyTxType {
+		return Address{}, ErrTxTypeNotSupported
+	}
+	if tx.V == nil || tx.R == nil || tx.S == nil {
+		return Address{}, fmt.Errorf("%w: missing signature", ErrInvalidSig)
+	}
+	if tx.V.Cmp(big.NewInt(27)) != 0 && tx.V.Cmp(big.NewInt(28)) != 0 {
+		return Address{}, fmt.Errorf("%w: V = %v", ErrInvalidSig, tx.V)
+	}
+	hash, err := s.SignatureHash(tx)
+	if err != nil {
+		return Address{}, err
+	}
+	return recoverSender(hash, tx.R, tx.S, byte(tx.V.Uint64()-27))
+}
+
+// SignTx 签名交易，V = recoveryID + 27
+func (s HomesteadSigner) SignTx(tx *Transaction, key *ecdsa.PrivateKey) (*Transaction, error) {
+	if tx.Type != LegacyTxType {
+		return nil, ErrTxTypeNotSupported
+	}
+	hash, err := s.SignatureHash(tx)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := crypto.Sign(hash, key)
+	if err != nil {
+		return nil, err
+	}
+	v := big.NewInt(int64(sig[64]) + 27)
+	tx.ChainID = nil
+	return tx.withSignature(sig, v), nil
+}
+
+// EIP155Signer 实现 EIP-155 重放保护：签名哈希包含链 ID，
+// V = recoveryID + chainID*2 + 35。
+// 未受保护（V 为 27/28）的交易按 Homestead 规则恢复发送者。
+type EIP155Signer struct {
+	chainID    *big.Int
+	chainIDMul *big.Int
+}
+
+// NewEIP155Signer 创建指定链 ID 的签名器
+func NewEIP155Signer(chainID *big.Int) EIP155Signer {
+	if chainID == nil {
+		chainID = new(big.Int)
+	}
+	return EIP155Signer{
+		chainID:    chainID,
+		chainIDMul: new(big.Int).Mul(chainID, big.NewInt(2)),
+	}
+}
+
+// ChainID 返回签名器的链 ID
+func (s EIP155Signer) ChainID() *big.Int {
+	return s.chainID
+}
+
+func (s EIP155Signer) Equal(other Signer) bool {
+	eip155, ok := other.(EIP155Signer)
+	return ok && eip155.chainID.Cmp(s.chainID) == 0
+}
+
+// SignatureHash 返回交易的签名哈希，按 EIP-155 在签名字段后追加 chainID, 0, 0
+func (s EIP155Signer) SignatureHash(tx *Transaction) ([]byte, error) {
+	return rlpHash(append(tx.signingFields(), s.chainID, uint(0), uint(0)))
+}
+
+// Sender 从签名中恢复发送者地址，签名属于其他链时返回 ErrInvalidChainID
+func (s EIP155Signer) Sender(tx *Transaction) (Address, error) {
+	if tx.Type != LegacyTxType {
+		return Address{}, ErrTxTypeNotSupported
+	}
+	if !tx.Protected() {
+		return HomesteadSigner{}.Sender(tx)
+	}
+	if tx.R == nil || tx.S == nil {
+		return Address{}, fmt.Errorf("%w: missing signature", ErrInvalidSig)
+	}
+	if chainID := deriveChainID(tx.V); chainID.Cmp(s.chainID) != 0 {
+		return Address{}, fmt.Errorf("%w: have %v want %v", ErrInvalidChainID, chainID, s.chainID)
+	}
+	// recoveryID = V - chainID*2 - 35
+	recID := new(big.Int).Sub(tx.V, s.chainIDMul)
+	recID.Sub(recID, big35)
+	hash, err := s.SignatureHash(tx)
+	if err != nil {
+		return Address{}, err
+	}
+	return recoverSender(hash, tx.R, tx.S, byte(recID.Uint64()))
+}
+
+// SignTx 签名交易，V = recoveryID + chainID*2 + 35
+func (s EIP155Signer) SignTx(tx *Transaction, key *ecdsa.PrivateKey) (*Transaction, error) {
+	if tx.Type != LegacyTxType {
+		return nil, ErrTxTypeNotSupported
+	}
+	hash, err := s.SignatureHash(tx)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := crypto.Sign(hash, key)
+	if err != nil {
+		return nil, err
+	}
+	v := new(big.Int).SetUint64(uint64(sig[64]) + 35)
+	v.Add(v, s.chainIDMul)
+	tx.ChainID = s.chainID
+	return tx.withSignature(sig, v), nil
+}
+
+// BerlinSigner 在 EIP-155 的基础上支持 EIP-2930 访问列表交易。
+// 类型化交易显式携带链 ID，签名哈希带类型前缀，V 为 recoveryID（0/1）
+type BerlinSigner struct {
+	EIP155Signer
+}
+
+// NewBerlinSigner 创建指定链 ID 的 Berlin 签名器
+func NewBerlinSigner(chainID *big.Int) BerlinSigner {
+	return BerlinSigner{NewEIP155Signer(chainID)}
+}
+
+func (s BerlinSigner) Equal(other Signer) bool {
+	berlin, ok := other.(BerlinSigner)
+	return ok && berlin.chainID.Cmp(s.chainID) == 0
+}
+
+// SignatureHash 返回交易的签名哈希，legacy 交易按 EIP-155 计算
+func (s BerlinSigner) SignatureHash(tx *Transaction) ([]byte, error) {
+	if tx.Type != AccessListTxType {
+		return s.EIP155Signer.SignatureHash(tx)
+	}
+	return tx.typedSigningHash(s.chainID)
+}
+
+// Sender 从签名中恢复发送者地址，交易的链 ID 必须与签名器一致
+func (s BerlinSigner) Sender(tx *Transaction) (Address, error) {
+	if tx.Type != AccessListTxType {
+		return s.EIP155Signer.Sender(tx)
+	}
+	return typedSender(s.chainID, tx)
+}
+
+// SignTx 签名交易；访问列表交易未设置链 ID 时使用签名器的链 ID
+func (s BerlinSigner) SignTx(tx *Transaction, key *ecdsa.PrivateKey) (*Transaction, error) {
+	if tx.Type != AccessListTxType {
+		return s.EIP155Signer.SignTx(tx, key)
+	}
+	return signTyped(s.chainID, tx, key)
+}
+
+// LondonSigner 在 Berlin 的基础上支持 EIP-1559 交易
+type LondonSigner struct {
+	BerlinSigner
+}
+
+// NewLondonSigner 创建指定链 ID 的 London 签名器
+func NewLondonSigner(chainID *big.Int) LondonSigner {
+	return LondonSigner{NewBerlinSigner(chainID)}
+}
+
+func (s LondonSigner) Equal(other Signer) bool {
+	london, ok := other.(LondonSigner)
+	return ok && london.chainID.Cmp(s.chainID) == 0
+}
+
+// SignatureHash 返回交易的签名哈希，其他类型交给 Berlin 规则处理
+func (s LondonSigner) SignatureHash(tx *Transaction) ([]byte, error) {
+	if tx.Type != DynamicFeeTxType {
+		return s.BerlinSigner.SignatureHash(tx)
+	}
+	return tx.typedSigningHash(s.chainID)
+}
+
+// Sender 从签名中恢复发送者地址，交易的链 ID 必须与签名器一致
+func (s LondonSigner) Sender(tx *Transaction) (Address, error) {
+	if tx.Type != DynamicFeeTxType {
+		return s.BerlinSigner.Sender(tx)
+	}
+	return typedSender(s.chainID, tx)
+}
+
+// SignTx 签名交易；EIP-1559 交易未设置链 ID 时使用签名器的链 ID
+func (s LondonSigner) SignTx(tx *Transaction, key *ecdsa.PrivateKey) (*Transaction, error) {
+	if tx.Type != DynamicFeeTxType {
+		return s.BerlinSigner.SignTx(tx, key)
+	}
+	return signTyped(s.chainID, tx, key)
+}
+
+// typedSender 恢复类型化交易的发送者：链 ID 必须为 chainID，V 必须为 0 或 1
+func typedSender(chainID *big.Int, tx *Transaction) (Address, error) {
+	if tx.V == nil || tx.R == nil || tx.S == nil {
+		return Address{}, fmt.Errorf("%w: missing signature", ErrInvalidSig)
+	}
+	if tx.ChainID == nil || tx.ChainID.Cmp(chainID) != 0 {
+		return Address{}, fmt.Errorf("%w: have %v want %v", ErrInvalidChainID, tx.ChainID, chainID)
+	}
+	if !tx.V.IsUint64() || tx.V.Uint64() > 1 {
+		return Address{}, fmt.Errorf("%w: V = %v", ErrInvalidSig, tx.V)
+	}
+	hash, err := tx.typedSigningHash(chainID)
+	if err != nil {
+		return Address{}, err
+	}
+	return recoverSender(hash, tx.R, tx.S, byte(tx.V.Uint64()))
+}
+
+// signTyped 用 chainID 签名类型化交易，V = recoveryID
+func signTyped(chainID *big.Int, tx *Transaction, key *ecdsa.PrivateKey) (*Transaction, error) {
+	if tx.ChainID == nil {
+		tx.ChainID = chainID
+	} else if tx.ChainID.Cmp(chainID) != 0 {
+		return nil, fmt.Errorf("%w: have %v want %v", ErrInvalidChainID, tx.ChainID, chainID)
+	}
+	hash, err := tx.typedSigningHash(chainID)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := crypto.Sign(hash, key)
+	if err != nil {
+		return nil, err
+	}
+	return tx.withSignature(sig, big.NewInt(int64(sig[64]))), nil
+}
+
+// withSignature 写入签名值并清除旧的发送者缓存
+func (tx *Transaction) withSignature(sig []byte, v *big.Int) *Transaction {
+	tx.R = new(big.Int).SetBytes(sig[:32])
+	tx.S = new(big.Int).SetBytes(sig[32:64])
+	tx.V = v
+	tx.from.Store(nil)
+	return tx
+}
+
+// deriveChainID 从 EIP-155 的 V 值中解出链 ID：(V - 35) / 2
+func deriveChainID(v *big.Int) *big.Int {
+	chainID := new(big.Int).Sub(v, big35)
+	return chainID.Rsh(chainID, 1)
+}
+
+// signatureBytes 构造 65 字节签名数据：r||s||recoveryID
+func signatureBytes(r, s *big.Int, recID byte) []byte {
+	sig := make([]byte, 65)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:64])
+	sig[64] = recID
+	return sig
+}
+
+// recoverSender 按签名哈希与 r、s、recoveryID 恢复地址。
+// r、s 必须在 [1, N) 内且 s <= N/2（low-S），防止签名可塑性。
+func recoverSender(hash []byte, r, s *big.Int, recID byte) (Address, error) {
+	if !crypto.ValidateSignatureValues(recID, r, s, true) {
+		return Address{}, ErrInvalidSig
+	}
+	pubKeyBytes, err := secp256k1.RecoverPubkey(hash, signatureBytes(r, s, recID))
+	if err != nil {
+		return Address{}, fmt.Errorf("%w: %v", ErrInvalidSig, err)
+	}
+	var addr Address
+	copy(addr[:], crypto.Keccak256(pubKeyBytes[1:])[12:])
+	return addr, nil
+}
@@ -0,0 +1,166 @@
+package common_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"CHAIN/common"
+	"CHAIN/params"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestEIP155Signer(t *testing.T) {
+	privKey, err := ethcrypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var from common.Address
+	copy(from[:], ethcrypto.PubkeyToAddress(privKey.PublicKey).Bytes())
+
+	to := common.HexToAddress("0x0000000000000000000000000000000000000009")
+	tx := &common.Transaction{
+		To:       &to,
+		Value:    big.NewInt(1),
+		GasLimit: 21000,
+		GasPrice: big.NewInt(1),
+		Nonce:    1,
+	}
+
+	signer := common.NewEIP155Signer(big.NewInt(1337))
+	if _, err := signer.SignTx(tx, privKey); err != nil {
+		t.Fatal(err)
+	}
+
+	if !tx.Protected() || tx.ChainID.Int64() != 1337 {
+		t.Fatalf("tx not protected for chain 1337, V=%v", tx.V)
+	}
+	sender, err := signer.Sender(tx)
+	if err != nil || sender != from {
+		t.Fatalf("Sender = %x, %v; want %x", sender, err, from)
+	}
+	if got, err := tx.From(); err != nil || got != from {
+		t.Fatalf("From() = %x, %v; want %x", got, err, from)
+	}
+
+	// 同一笔交易在其他链上被拒绝
+	other := common.NewEIP155Signer(big.NewInt(1))
+	if _, err := other.Sender(tx); !errors.Is(err, common.ErrInvalidChainID) {
+		t.Fatalf("Sender on other chain = %v, want ErrInvalidChainID", err)
+	}
+	if string(mustSigHash(t, other, tx)) == string(mustSigHash(t, signer, tx)) {
+		t.Fatal("signing hash does not commit to chain id")
+	}
+}
+
+func TestHomesteadSigner(t *testing.T) {
+	privKey, _ := ethcrypto.GenerateKey()
+	var from common.Address
+	copy(from[:], ethcrypto.PubkeyToAddress(privKey.PublicKey).Bytes())
+
+	tx := &common.Transaction{Value: big.NewInt(1), GasPrice: big.NewInt(1), Nonce: 1}
+	if _, err := (common.HomesteadSigner{}).SignTx(tx, privKey); err != nil {
+		t.Fatal(err)
+	}
+	if tx.Protected() {
+		t.Fatalf("homestead tx should not be protected, V=%v", tx.V)
+	}
+
+	// EIP-155 签名器也接受未受保护的交易
+	for _, signer := range []common.Signer{common.HomesteadSigner{}, common.NewEIP155Signer(big.NewInt(1337))} {
+		sender, err := common.Sender(signer, tx)
+		if err != nil || sender != from {
+			t.Fatalf("%T Sender = %x, %v; want %x", signer, sender, err, from)
+		}
+	}
+
+	// Homestead 签名器拒绝 EIP-155 交易
+	protected := &common.Transaction{Value: big.NewInt(1), GasPrice: big.NewInt(1), Nonce: 1}
+	common.NewEIP155Signer(big.NewInt(1)).SignTx(protected, privKey)
+	if _, err := (common.HomesteadSigner{}).Sender(protected); err == nil {
+		t.Fatal("homestead signer accepted protected tx")
+	}
+}
+
+func TestSenderCache(t *testing.T) {
+	privKey, _ := ethcrypto.GenerateKey()
+	signer := common.NewEIP155Signer(big.NewInt(1337))
+	tx := &common.Transaction{Value: big.NewInt(1), GasPrice: big.NewInt(1), Nonce: 1}
+	signer.SignTx(tx, privKey)
+
+	first, err := common.Sender(signer, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 篡改签名后，同一签名器仍返回缓存结果
+	tx.R = big.NewInt(1)
+	cached, err := common.Sender(signer, tx)
+	if err != nil || cached != first {
+		t.Fatalf("cached Sender = %x, %v; want %x", cached, err, first)
+	}
+	// 重新签名会清除缓存
+	otherKey, _ := ethcrypto.GenerateKey()
+	signer.SignTx(tx, otherKey)
+	if again, _ := common.Sender(signer, tx); again == first {
+		t.Fatal("sender cache not invalidated by SignTx")
+	}
+}
+
+func TestMakeSigner(t *testing.T) {
+	config := &params.ChainConfig{ChainID: big.NewInt(5), EIP155Block: big.NewInt(10)}
+	if _, ok := common.MakeSigner(config, 9).(common.HomesteadSigner); !ok {
+		t.Error("expected homestead signer before EIP-155 block")
+	}
+	signer := common.MakeSigner(config, 10)
+	if !signer.Equal(common.NewEIP155Signer(big.NewInt(5))) {
+		t.Errorf("expected EIP-155 signer for chain 5, got %T", signer)
+	}
+
+	config.BerlinBlock = big.NewInt(20)
+	config.LondonBlock = big.NewInt(30)
+	if s := common.MakeSigner(config, 20); !s.Equal(common.NewBerlinSigner(big.NewInt(5))) {
+		t.Errorf("expected Berlin signer at block 20, got %T", s)
+	}
+	if s := common.MakeSigner(config, 30); !s.Equal(common.NewLondonSigner(big.NewInt(5))) {
+		t.Errorf("expected London signer at block 30, got %T", s)
+	}
+}
+
+func TestSenderRejectsInvalidSignatures(t *testing.T) {
+	privKey, _ := ethcrypto.GenerateKey()
+	signer := common.NewEIP155Signer(big.NewInt(1337))
+	newTx := func() *common.Transaction {
+		tx := &common.Transaction{Value: big.NewInt(1), GasPrice: big.NewInt(1), Nonce: 1}
+		signer.SignTx(tx, privKey)
+		return tx
+	}
+
+	// 高位 S（s' = N - s）是同一签名的可塑变体，必须拒绝
+	secp256k1N := ethcrypto.S256().Params().N
+	highS := newTx()
+	highS.S = new(big.Int).Sub(secp256k1N, highS.S)
+
+	badV := newTx()
+	badV.V = big.NewInt(30)
+
+	zeroR := newTx()
+	zeroR.R = new(big.Int)
+
+	missing := newTx()
+	missing.S = nil
+
+	for name, tx := range map[string]*common.Transaction{
+		"high S": highS, "bad V": badV, "zero R": zeroR, "missing S": missing,
+	} {
+		if _, err := common.Sender(signer, tx); !errors.Is(err, common.ErrInvalidSig) {
+			t.Errorf("%s: Sender = %v, want ErrInvalidSig", name, err)
+		}
+		if _, err := tx.From(); err == nil {
+			t.Errorf("%s: From() should fail", name)
+		}
+	}
+
+	if _, err := common.RecoverAddress(make([]byte, 32), big.NewInt(1), big.NewInt(2), 29); !errors.Is(err, common.ErrInvalidSig) {
+		t.Errorf("RecoverAddress with bad v = %v, want ErrInvalidSig", err)
+	}
+}
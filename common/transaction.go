@@ -2,93 +2,162 @@ package common
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/crypto/secp256k1"
 	"math/big"
+	"sync/atomic"
+)
+
+// 交易类型，与以太坊的类型编号一致
+const (
+	LegacyTxType     = 0x00 // 传统交易，GasPrice 定价
+	AccessListTxType = 0x01 // EIP-2930 交易，GasPrice 定价并携带访问列表
+	DynamicFeeTxType = 0x02 // EIP-1559 交易，GasFeeCap/GasTipCap 定价
+)
+
+var (
+	// ErrTxTypeNotSupported 表示交易类型未知或当前签名器不支持
+	ErrTxTypeNotSupported = errors.New("transaction type not supported")
+	// ErrFeeCapTooLow 表示交易的最高 Gas 价格低于区块基础费用
+	ErrFeeCapTooLow = errors.New("max fee per gas less than block base fee")
+	// ErrNegativeValue 表示交易的金额或价格字段为负数
+	ErrNegativeValue = errors.New("negative value")
 )
 
 type Transaction struct {
-	*types.Transaction // 嵌入 go-ethereum 的 Transaction，
-	R, S               *big.Int
-	V                  uint8
-	GasPrice           *big.Int
+	Type    uint8    // 交易类型，见 LegacyTxType / AccessListTxType / DynamicFeeTxType
+	ChainID *big.Int // 链 ID；legacy 交易由 EIP-155 的 V 推导
+
+	R, S *big.Int
+	V    *big.Int // legacy 为 27/28 或 chainID*2+35/36；类型化交易为 0/1
+
+	GasPrice  *big.Int // legacy 交易的 Gas 价格
+	GasTipCap *big.Int // EIP-1559：愿意支付给出块者的最高小费
+	GasFeeCap *big.Int // EIP-1559：每单位 Gas 愿意支付的最高总价
 	// 基础字段
-	Fro Address  // 发送方地址
-	To  *Address // 接收方地址(合约创建时为nil)
+	To *Address // 接收方地址(合约创建时为nil)
 
 	// 新增的核心字段
-	GasLimit uint64   // 交易消耗的Gas上限
-	Value    *big.Int // 转账金额(wei)
-	Input    []byte   // 交易输入数据(合约调用时使用)
+	GasLimit   uint64     // 交易消耗的Gas上限
+	Value      *big.Int   // 转账金额(wei)
+	Input      []byte     // 交易输入数据(合约调用时使用)
+	AccessList AccessList // EIP-2930 访问列表，仅类型化交易使用
 
 	// 其他原有字段...
-	Nonce     uint64 // 交易序号
-	Signature []byte // 交易签
+	Nonce uint64 // 交易序号
+
+	from atomic.Pointer[sigCache] // 缓存恢复出的发送者
 }
 
-// NewTransaction 构造函数：从 types.Transaction 复制构造 common.Transaction
-func NewTransaction(tx *types.Transaction, R, S *big.Int, V uint8) *Transaction {
-	return &Transaction{
-		Transaction: tx,
-		R:           R,
-		S:           S,
-		V:           V,
+// AccessTuple 是访问列表中的一项：地址及其存储槽
+type AccessTuple struct {
+	Address     Address
+	StorageKeys []Hash
+}
+
+// AccessList 是 EIP-2930 访问列表
+type AccessList []AccessTuple
+
+// From 返回发送者地址，通过签名恢复公钥再转地址。
+// 签名器由交易类型和签名中携带的链 ID 推断，因此不校验链 ID；
+// 需要重放保护时应使用 Sender(signer, tx)。
+func (tx *Transaction) From() (Address, error) {
+	var signer Signer = HomesteadSigner{}
+	switch {
+	case tx.Type != LegacyTxType:
+		signer = NewLondonSigner(bigOrZero(tx.ChainID))
+	case tx.Protected():
+		signer = NewEIP155Signer(deriveChainID(tx.V))
 	}
+	return Sender(signer, tx)
 }
 
-// From 返回发送者地址，通过签名恢复公钥再转地址
-// From 返回发送者地址，通过签名恢复公钥再转地址
-func (tx *Transaction) From() Address {
-	hash := tx.Hash() // 1. 获取交易哈希（不包含签名部分）
-
-	// 2. 构造 65 字节签名数据：r||s||v
-	sig := make([]byte, 65)
-	copy(sig[32-len(tx.R.Bytes()):32], tx.R.Bytes()) // r 填充到前 32 字节
-	copy(sig[64-len(tx.S.Bytes()):64], tx.S.Bytes()) // s 填充到中间 32 字节
-
-	// 支持 EIP-155 计算 recovery id
-	var recID byte
-	if tx.V == 27 || tx.V == 28 {
-		recID = byte(tx.V - 27)
-	} else if tx.V >= 35 {
-		recID = byte((uint64(tx.V) - 35) % 2)
-	} else {
-		panic(fmt.Sprintf("invalid V value for signature recovery: %d", tx.V))
+// Protected 判断交易是否受重放保护：类型化交易总是带链 ID，
+// legacy 交易需要使用 EIP-155 签名（V >= 35）
+func (tx *Transaction) Protected() bool {
+	if tx.Type != LegacyTxType {
+		return true
 	}
+	return tx.V != nil && tx.V.Cmp(big35) >= 0
+}
 
-	sig[64] = recID
+// GasPriceUint64 返回交易的 Gas 价格，EIP-1559 交易返回 GasFeeCap
+func (tx *Transaction) GasPriceUint64() uint64 {
+	return tx.FeeCap().Uint64()
+}
 
-	// 3. 恢复未压缩公钥
-	pubKeyBytes, err := secp256k1.RecoverPubkey(hash, sig)
-	if err != nil {
-		panic(fmt.Sprintf("signature recovery failed: %v", err))
+// FeeCap 返回每单位 Gas 愿意支付的最高价格，legacy 交易即 GasPrice
+func (tx *Transaction) FeeCap() *big.Int {
+	if tx.Type == DynamicFeeTxType {
+		return bigOrZero(tx.GasFeeCap)
 	}
+	return bigOrZero(tx.GasPrice)
+}
 
-	// 4. 解码为 ecdsa 公钥结构
-	pubKey, err := crypto.UnmarshalPubkey(pubKeyBytes)
-	if err != nil {
-		panic(fmt.Sprintf("invalid pubkey bytes: %v", err))
+// TipCap 返回每单位 Gas 愿意支付给出块者的最高小费，legacy 交易即 GasPrice
+func (tx *Transaction) TipCap() *big.Int {
+	if tx.Type == DynamicFeeTxType {
+		return bigOrZero(tx.GasTipCap)
 	}
+	return bigOrZero(tx.GasPrice)
+}
 
-	// 5. 获取地址（keccak256(pubkey[1:])[12:]）
-	pubBytes := crypto.FromECDSAPub(pubKey)[1:] // 跳过前缀 0x04
-	addressBytes := crypto.Keccak256(pubBytes)[12:]
+// EffectiveGasTip 返回在给定基础费用下出块者实际获得的每单位 Gas 小费：
+// min(TipCap, FeeCap-baseFee)。FeeCap 低于基础费用时返回负值及 ErrFeeCapTooLow。
+// baseFee 为 nil（London 之前）时返回 TipCap
+func (tx *Transaction) EffectiveGasTip(baseFee *big.Int) (*big.Int, error) {
+	if baseFee == nil {
+		return new(big.Int).Set(tx.TipCap()), nil
+	}
+	tip := new(big.Int).Sub(tx.FeeCap(), baseFee)
+	if tip.Sign() < 0 {
+		return tip, ErrFeeCapTooLow
+	}
+	if tipCap := tx.TipCap(); tipCap.Cmp(tip) < 0 {
+		tip.Set(tipCap)
+	}
+	return tip, nil
+}
 
-	var addr Address
-	copy(addr[:], addressBytes)
-	return addr
+// EffectiveGasPrice 返回在给定基础费用下每单位 Gas 的实际价格：baseFee + 有效小费
+func (tx *Transaction) EffectiveGasPrice(baseFee *big.Int) *big.Int {
+	tip, _ := tx.EffectiveGasTip(baseFee)
+	if baseFee == nil {
+		return tip
+	}
+	return tip.Add(tip, baseFee)
 }
 
-func (tx *Transaction) GasPriceUint64() uint64 {
-	if tx.GasPrice == nil {
-		return 0
+// ValidateValues 检查交易的金额与价格字段不为负数。
+// 负数无法 RLP 编码，应在计算哈希或恢复发送者之前检查
+func (tx *Transaction) ValidateValues() error {
+	for _, field := range []struct {
+		name  string
+		value *big.Int
+	}{
+		{"value", tx.Value},
+		{"gas price", tx.GasPrice},
+		{"gas fee cap", tx.GasFeeCap},
+		{"gas tip cap", tx.GasTipCap},
+	} {
+		if field.value != nil && field.value.Sign() < 0 {
+			return fmt.Errorf("%w: %s %v", ErrNegativeValue, field.name, field.value)
+		}
 	}
-	return tx.GasPrice.Uint64()
+	return nil
+}
+
+// Cost 返回交易最多花费的金额：GasLimit * FeeCap + Value
+func (tx *Transaction) Cost() *big.Int {
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(tx.GasLimit), tx.FeeCap())
+	return cost.Add(cost, bigOrZero(tx.Value))
 }
 
-// Hex 返回交易哈希的十六进制字符串表示
+// Hex 返回交易哈希的十六进制字符串表示，交易无法编码时返回空串
 func (tx *Transaction) Hex() string {
-	return hex.EncodeToString(tx.Hash())
+	hash, err := tx.Hash()
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(hash)
 }
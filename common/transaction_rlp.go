@@ -0,0 +1,250 @@
+package common
+
+import (
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var errShortTypedTx = errors.New("typed transaction too short")
+
+// txRLP 是交易的规范 RLP 编码，与以太坊 legacy 交易格式相同：
+// [nonce, gasPrice, gas, to, value, data, v, r, s]
+type txRLP struct {
+	Nonce    uint64
+	GasPrice *big.Int
+	Gas      uint64
+	To       *Address `rlp:"nil"` // 合约创建时编码为空串
+	Value    *big.Int
+	Data     []byte
+	V, R, S  *big.Int
+}
+
+// 类型化交易使用 EIP-2718 信封编码：type || payload，
+// 其中 type 为 [0, 0x7f] 内的一个字节，payload 为该类型自定义的 RLP 编码。
+// legacy 交易的 RLP 列表首字节不小于 0xc0，因此两种格式可以直接区分。
+
+// accessListTxRLP 是 EIP-2930 访问列表交易的负载编码：
+// 0x01 || rlp([chainId, nonce, gasPrice, gas, to, value, data, accessList, v, r, s])
+type accessListTxRLP struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasPrice   *big.Int
+	Gas        uint64
+	To         *Address `rlp:"nil"`
+	Value      *big.Int
+	Data       []byte
+	AccessList AccessList
+	V, R, S    *big.Int
+}
+
+// dynamicFeeTxRLP 是 EIP-1559 交易的负载编码：
+// 0x02 || rlp([chainId, nonce, gasTipCap, gasFeeCap, gas, to, value, data, accessList, v, r, s])
+type dynamicFeeTxRLP struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         *Address `rlp:"nil"`
+	Value      *big.Int
+	Data       []byte
+	AccessList AccessList
+	V, R, S    *big.Int
+}
+
+// EncodeRLP 实现 rlp.Encoder。legacy 交易编码为 RLP 列表，
+// 类型化交易与以太坊一致，编码为包含 type||payload 的字节串。
+// nil 的数值字段按 0 编码
+func (tx *Transaction) EncodeRLP(w io.Writer) error {
+	if tx.Type == LegacyTxType {
+		return rlp.Encode(w, tx.legacyRLP())
+	}
+	enc, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return rlp.Encode(w, enc)
+}
+
+// DecodeRLP 实现 rlp.Decoder，解码后发送者缓存被清空
+func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
+	kind, _, err := s.Kind()
+	if err != nil {
+		return err
+	}
+	if kind == rlp.List {
+		var dec txRLP
+		if err := s.Decode(&dec); err != nil {
+			return err
+		}
+		tx.setLegacy(&dec)
+		return nil
+	}
+	b, err := s.Bytes()
+	if err != nil {
+		return err
+	}
+	return tx.decodeTyped(b)
+}
+
+// MarshalBinary 返回交易的规范编码：legacy 交易为 RLP 列表，
+// 类型化交易为 type||rlp(payload)
+func (tx *Transaction) MarshalBinary() ([]byte, error) {
+	if tx.Type == LegacyTxType {
+		return rlp.EncodeToBytes(tx.legacyRLP())
+	}
+	payload, err := tx.typedPayload()
+	if err != nil {
+		return nil, err
+	}
+	enc, err := rlp.EncodeToBytes(payload)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{tx.Type}, enc...), nil
+}
+
+// UnmarshalBinary 从规范编码中解码交易
+func (tx *Transaction) UnmarshalBinary(b []byte) error {
+	// RLP 列表的首字节不小于 0xc0，类型字节位于 [0, 0x7f]
+	if len(b) > 0 && b[0] > 0x7f {
+		var dec txRLP
+		if err := rlp.DecodeBytes(b, &dec); err != nil {
+			return err
+		}
+		tx.setLegacy(&dec)
+		return nil
+	}
+	return tx.decodeTyped(b)
+}
+
+func (tx *Transaction) legacyRLP() *txRLP {
+	return &txRLP{
+		Nonce:    tx.Nonce,
+		GasPrice: bigOrZero(tx.GasPrice),
+		Gas:      tx.GasLimit,
+		To:       tx.To,
+		Value:    bigOrZero(tx.Value),
+		Data:     tx.Input,
+		V:        bigOrZero(tx.V),
+		R:        bigOrZero(tx.R),
+		S:        bigOrZero(tx.S),
+	}
+}
+
+// typedPayload 返回类型化交易信封中 payload 部分的编码结构
+func (tx *Transaction) typedPayload() (interface{}, error) {
+	switch tx.Type {
+	case AccessListTxType:
+		return &accessListTxRLP{
+			ChainID:    bigOrZero(tx.ChainID),
+			Nonce:      tx.Nonce,
+			GasPrice:   bigOrZero(tx.GasPrice),
+			Gas:        tx.GasLimit,
+			To:         tx.To,
+			Value:      bigOrZero(tx.Value),
+			Data:       tx.Input,
+			AccessList: tx.AccessList,
+			V:          bigOrZero(tx.V),
+			R:          bigOrZero(tx.R),
+			S:          bigOrZero(tx.S),
+		}, nil
+	case DynamicFeeTxType:
+		return &dynamicFeeTxRLP{
+			ChainID:    bigOrZero(tx.ChainID),
+			Nonce:      tx.Nonce,
+			GasTipCap:  bigOrZero(tx.GasTipCap),
+			GasFeeCap:  bigOrZero(tx.GasFeeCap),
+			Gas:        tx.GasLimit,
+			To:         tx.To,
+			Value:      bigOrZero(tx.Value),
+			Data:       tx.Input,
+			AccessList: tx.AccessList,
+			V:          bigOrZero(tx.V),
+			R:          bigOrZero(tx.R),
+			S:          bigOrZero(tx.S),
+		}, nil
+	default:
+		return nil, ErrTxTypeNotSupported
+	}
+}
+
+func (tx *Transaction) setLegacy(dec *txRLP) {
+	*tx = Transaction{
+		Type:     LegacyTxType,
+		Nonce:    dec.Nonce,
+		GasPrice: dec.GasPrice,
+		GasLimit: dec.Gas,
+		To:       dec.To,
+		Value:    dec.Value,
+		Input:    dec.Data,
+		V:        dec.V,
+		R:        dec.R,
+		S:        dec.S,
+	}
+	if tx.Protected() {
+		tx.ChainID = deriveChainID(tx.V)
+	}
+}
+
+// decodeTyped 解码 type||payload 形式的类型化交易
+func (tx *Transaction) decodeTyped(b []byte) error {
+	if len(b) <= 1 {
+		return errShortTypedTx
+	}
+	switch b[0] {
+	case AccessListTxType:
+		var dec accessListTxRLP
+		if err := rlp.DecodeBytes(b[1:], &dec); err != nil {
+			return err
+		}
+		*tx = Transaction{
+			Type:       AccessListTxType,
+			ChainID:    dec.ChainID,
+			Nonce:      dec.Nonce,
+			GasPrice:   dec.GasPrice,
+			GasLimit:   dec.Gas,
+			To:         dec.To,
+			Value:      dec.Value,
+			Input:      dec.Data,
+			AccessList: dec.AccessList,
+			V:          dec.V,
+			R:          dec.R,
+			S:          dec.S,
+		}
+		return nil
+	case DynamicFeeTxType:
+		var dec dynamicFeeTxRLP
+		if err := rlp.DecodeBytes(b[1:], &dec); err != nil {
+			return err
+		}
+		*tx = Transaction{
+			Type:       DynamicFeeTxType,
+			ChainID:    dec.ChainID,
+			Nonce:      dec.Nonce,
+			GasTipCap:  dec.GasTipCap,
+			GasFeeCap:  dec.GasFeeCap,
+			GasLimit:   dec.Gas,
+			To:         dec.To,
+			Value:      dec.Value,
+			Input:      dec.Data,
+			AccessList: dec.AccessList,
+			V:          dec.V,
+			R:          dec.R,
+			S:          dec.S,
+		}
+		return nil
+	default:
+		return ErrTxTypeNotSupported
+	}
+}
+
+func bigOrZero(x *big.Int) *big.Int {
+	if x == nil {
+		return new(big.Int)
+	}
+	return x
+}
@@ -1,23 +1,17 @@
 package common_test
 
 import (
-	"encoding/hex"
+	"bytes"
+	"errors"
 	"math/big"
-	"strings"
 	"testing"
 
 	"CHAIN/common"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	ethcrypto "github.com/ethereum/go-ethereum/crypto"
 )
 
-// HexToAddress 同前
-func HexToAddress(s string) common.Address {
-	var addr common.Address
-	b, _ := hex.DecodeString(strings.TrimPrefix(s, "0x"))
-	copy(addr[:], b)
-	return addr
-}
-
 // 生成并签名交易，返回 *types.Transaction 和私钥对应的 common.Address
 // 生成签名后的 common.Transaction，返回交易对象和对应地址
 func createSignedCommonTx(t *testing.T) (*common.Transaction, common.Address) {
@@ -32,38 +26,26 @@ func createSignedCommonTx(t *testing.T) (*common.Transaction, common.Address) {
 
 	// 1. 先构造未签名的 tx
 	tx := &common.Transaction{
-		Fro:   from,
 		Value: big.NewInt(12345),
 		Nonce: 0,
 		Input: []byte{},
 	}
 
-	// 2. 获取签名哈希
-	hash := tx.Hash()
-
-	// 3. 对哈希签名
-	sig, err := ethcrypto.Sign(hash, privKey)
-	if err != nil {
+	// 2. 用签名器签名，R、S、V 由签名器填写
+	if _, err := (common.HomesteadSigner{}).SignTx(tx, privKey); err != nil {
 		t.Fatal(err)
 	}
 
-	// 4. 拆解签名成 R,S,V
-	R := new(big.Int).SetBytes(sig[:32])
-	S := new(big.Int).SetBytes(sig[32:64])
-	V := uint8(sig[64]) + 27
-
-	// 5. 补充签名后的字段
-	tx.R = R
-	tx.S = S
-	tx.V = V
-
 	return tx, from
 }
 
 func TestCommonTransactionFrom(t *testing.T) {
 	tx, from := createSignedCommonTx(t)
 
-	recovered := tx.From()
+	recovered, err := tx.From()
+	if err != nil {
+		t.Fatalf("From() 返回错误: %v", err)
+	}
 	if recovered != from {
 		t.Fatalf("From() 地址不匹配，期望 %x 实际 %x", from, recovered)
 	}
@@ -72,3 +54,140 @@ func TestCommonTransactionFrom(t *testing.T) {
 		t.Error("Hex() 返回空字符串")
 	}
 }
+
+func TestTransactionHashCoversSignature(t *testing.T) {
+	key1, _ := ethcrypto.GenerateKey()
+	key2, _ := ethcrypto.GenerateKey()
+	to := common.Address{1}
+	newTx := func() *common.Transaction {
+		return &common.Transaction{To: &to, Nonce: 1, GasPrice: big.NewInt(10), GasLimit: 21000, Value: big.NewInt(5)}
+	}
+	signer := common.NewEIP155Signer(big.NewInt(1337))
+	tx1, _ := signer.SignTx(newTx(), key1)
+	tx2, _ := signer.SignTx(newTx(), key2)
+
+	if !bytes.Equal(mustSigHash(t, signer, tx1), mustSigHash(t, signer, tx2)) {
+		t.Fatal("signature hash must not depend on the signature")
+	}
+	if bytes.Equal(mustHash(t, tx1), mustHash(t, tx2)) {
+		t.Fatal("differently signed transactions share an ID")
+	}
+
+	// 任一字段变化都会改变交易 ID
+	tx3, _ := signer.SignTx(newTx(), key1)
+	tx3.GasPrice = big.NewInt(11)
+	if bytes.Equal(mustHash(t, tx1), mustHash(t, tx3)) {
+		t.Fatal("gas price is not covered by the transaction hash")
+	}
+}
+
+// 编码与签名哈希需要与以太坊 legacy 交易一致
+func TestTransactionEncodingMatchesEthereum(t *testing.T) {
+	key, _ := ethcrypto.GenerateKey()
+	to := common.Address{0xaa, 0xbb}
+	chainID := big.NewInt(1337)
+
+	for _, create := range []bool{false, true} {
+		tx := &common.Transaction{Nonce: 7, GasPrice: big.NewInt(3), GasLimit: 50000, Value: big.NewInt(99), Input: []byte{1, 2, 3}}
+		legacy := &types.LegacyTx{Nonce: 7, GasPrice: big.NewInt(3), Gas: 50000, Value: big.NewInt(99), Data: []byte{1, 2, 3}}
+		if !create {
+			tx.To = &to
+			ethTo := ethcommon.Address(to)
+			legacy.To = &ethTo
+		}
+
+		signer := common.NewEIP155Signer(chainID)
+		ethSigner := types.NewEIP155Signer(chainID)
+		if !bytes.Equal(mustSigHash(t, signer, tx), ethSigner.Hash(types.NewTx(legacy)).Bytes()) {
+			t.Fatalf("create=%v: EIP-155 signature hash differs from go-ethereum", create)
+		}
+		if !bytes.Equal(mustSigHash(t, common.HomesteadSigner{}, tx), types.HomesteadSigner{}.Hash(types.NewTx(legacy)).Bytes()) {
+			t.Fatalf("create=%v: homestead signature hash differs from go-ethereum", create)
+		}
+
+		signer.SignTx(tx, key)
+		ethTx, err := types.SignNewTx(key, ethSigner, legacy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(mustHash(t, tx), ethTx.Hash().Bytes()) {
+			t.Fatalf("create=%v: transaction hash differs from go-ethereum", create)
+		}
+
+		enc, err := tx.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded common.Transaction
+		if err := decoded.UnmarshalBinary(enc); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(mustHash(t, &decoded), mustHash(t, tx)) || (decoded.To == nil) != create {
+			t.Fatalf("create=%v: round trip changed the transaction", create)
+		}
+		from, err := common.Sender(signer, &decoded)
+		if err != nil || from != common.Address(ethcrypto.PubkeyToAddress(key.PublicKey)) {
+			t.Fatalf("create=%v: decoded sender = %x, %v", create, from, err)
+		}
+	}
+}
+
+func TestNegativeValuesRejected(t *testing.T) {
+	key, _ := ethcrypto.GenerateKey()
+	to := common.Address{1}
+	fields := map[string]func(tx *common.Transaction){
+		"value":       func(tx *common.Transaction) { tx.Value = big.NewInt(-1) },
+		"gas price":   func(tx *common.Transaction) { tx.GasPrice = big.NewInt(-1) },
+		"gas fee cap": func(tx *common.Transaction) { tx.GasFeeCap = big.NewInt(-1) },
+		"gas tip cap": func(tx *common.Transaction) { tx.GasTipCap = big.NewInt(-1) },
+	}
+	signer := common.NewLondonSigner(big.NewInt(1))
+	for name, set := range fields {
+		tx := &common.Transaction{Type: common.DynamicFeeTxType, To: &to, GasLimit: 21000, GasPrice: big.NewInt(1), Value: big.NewInt(1)}
+		if _, err := signer.SignTx(tx, key); err != nil {
+			t.Fatal(err)
+		}
+		set(tx)
+		if err := tx.ValidateValues(); !errors.Is(err, common.ErrNegativeValue) {
+			t.Fatalf("%s: ValidateValues = %v, want ErrNegativeValue", name, err)
+		}
+		// 无法编码的交易返回错误而不是 panic
+		if name != "gas price" {
+			if _, err := tx.Hash(); err == nil {
+				t.Fatalf("%s: Hash succeeded", name)
+			}
+			if _, err := common.Sender(signer, tx); err == nil {
+				t.Fatalf("%s: Sender succeeded", name)
+			}
+		}
+	}
+
+	// legacy 交易的签名哈希包含 GasPrice
+	legacy := &common.Transaction{To: &to, GasLimit: 21000, GasPrice: big.NewInt(-1)}
+	if _, err := signer.SignatureHash(legacy); err == nil {
+		t.Fatal("SignatureHash succeeded for negative gas price")
+	}
+	if _, err := legacy.Hash(); err == nil {
+		t.Fatal("Hash succeeded for negative gas price")
+	}
+}
+
+// mustHash 返回交易哈希，交易无法编码时测试失败
+func mustHash(t *testing.T, tx *common.Transaction) []byte {
+	t.Helper()
+	hash, err := tx.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+// mustSigHash 返回 signer 计算的签名哈希，交易无法编码时测试失败
+func mustSigHash(t *testing.T, signer common.Signer, tx *common.Transaction) []byte {
+	t.Helper()
+	hash, err := signer.SignatureHash(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
@@ -0,0 +1,232 @@
+package hdwallet
+
+import (
+	"CHAIN/common"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// HardenedOffset 是 BIP-32 强化派生的索引起点，路径中用 ' 表示
+const HardenedOffset uint32 = 0x80000000
+
+// DefaultBaseDerivationPath 是以太坊账户的 BIP-44 基础路径，
+// 第 i 个账户位于 m/44'/60'/0'/0/i
+var DefaultBaseDerivationPath = DerivationPath{44 + HardenedOffset, 60 + HardenedOffset, 0 + HardenedOffset, 0}
+
+var (
+	// ErrInvalidMnemonic 表示助记词不在词表中或校验和错误
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+	// ErrInvalidPath 表示无法解析的派生路径
+	ErrInvalidPath = errors.New("invalid derivation path")
+	// ErrInvalidSeed 表示种子长度不在 BIP-32 允许的 16~64 字节内
+	ErrInvalidSeed = errors.New("invalid seed length")
+	// errInvalidChild 对应 BIP-32 中派生出无效子密钥的情况（概率约 2^-127）
+	errInvalidChild = errors.New("derived key is invalid")
+)
+
+// NewMnemonic 生成 BIP-39 英文助记词，bits 为熵长度：128（12 个词）到 256（24 个词），须为 32 的倍数
+func NewMnemonic(bits int) (string, error) {
+	entropy, err := bip39.NewEntropy(bits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// ValidateMnemonic 校验助记词的词表与校验和
+func ValidateMnemonic(mnemonic string) error {
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return ErrInvalidMnemonic
+	}
+	return nil
+}
+
+// NewSeed 按 BIP-39 由助记词和可选密码生成 64 字节种子
+func NewSeed(mnemonic, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	return bip39.NewSeed(mnemonic, passphrase), nil
+}
+
+// DerivationPath 是 BIP-32 派生路径中各级的索引
+type DerivationPath []uint32
+
+// ParseDerivationPath 解析 m/44'/60'/0'/0/0 形式的路径，' 或 h 表示强化派生
+func ParseDerivationPath(path string) (DerivationPath, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if len(parts) == 0 || parts[0] != "m" {
+		return nil, fmt.Errorf("%w: %q must start with m", ErrInvalidPath, path)
+	}
+	var result DerivationPath
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+		index, err := strconv.ParseUint(part, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidPath, path, err)
+		}
+		if hardened {
+			index += uint64(HardenedOffset)
+		}
+		result = append(result, uint32(index))
+	}
+	return result, nil
+}
+
+// String 返回路径的文本形式，如 m/44'/60'/0'/0/0
+func (p DerivationPath) String() string {
+	var b strings.Builder
+	b.WriteString("m")
+	for _, index := range p {
+		b.WriteString("/")
+		if index >= HardenedOffset {
+			b.WriteString(strconv.FormatUint(uint64(index-HardenedOffset), 10))
+			b.WriteString("'")
+		} else {
+			b.WriteString(strconv.FormatUint(uint64(index), 10))
+		}
+	}
+	return b.String()
+}
+
+// AccountPath 返回默认基础路径下第 index 个账户的路径
+func AccountPath(index uint32) DerivationPath {
+	path := make(DerivationPath, len(DefaultBaseDerivationPath), len(DefaultBaseDerivationPath)+1)
+	copy(path, DefaultBaseDerivationPath)
+	return append(path, index)
+}
+
+// Wallet 是由同一个种子派生出全部账户的分层确定性钱包
+type Wallet struct {
+	master extendedKey
+}
+
+// extendedKey 是 BIP-32 扩展私钥：私钥与链码
+type extendedKey struct {
+	key       []byte // 32 字节私钥
+	chainCode []byte
+}
+
+// NewFromMnemonic 由助记词和可选密码创建钱包
+func NewFromMnemonic(mnemonic, passphrase string) (*Wallet, error) {
+	seed, err := NewSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromSeed(seed)
+}
+
+// NewFromSeed 由 BIP-32 种子创建钱包
+func NewFromSeed(seed []byte) (*Wallet, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("%w: %d bytes", ErrInvalidSeed, len(seed))
+	}
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	master := extendedKey{key: sum[:32], chainCode: sum[32:]}
+	if !validPrivateKey(master.key) {
+		return nil, errInvalidChild
+	}
+	return &Wallet{master: master}, nil
+}
+
+// Derive 派生指定路径上的私钥
+func (w *Wallet) Derive(path DerivationPath) (*ecdsa.PrivateKey, error) {
+	key := w.master
+	for _, index := range path {
+		var err error
+		if key, err = key.child(index); err != nil {
+			return nil, fmt.Errorf("derive %s: %w", path, err)
+		}
+	}
+	return crypto.ToECDSA(key.key)
+}
+
+// PrivateKey 返回 m/44'/60'/0'/0/index 上的私钥
+func (w *Wallet) PrivateKey(index uint32) (*ecdsa.PrivateKey, error) {
+	return w.Derive(AccountPath(index))
+}
+
+// Address 返回 m/44'/60'/0'/0/index 上的账户地址
+func (w *Wallet) Address(index uint32) (common.Address, error) {
+	priv, err := w.PrivateKey(index)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.Address(crypto.PubkeyToAddress(priv.PublicKey)), nil
+}
+
+// Addresses 返回前 n 个账户的地址，可直接用于创世分配
+func (w *Wallet) Addresses(n int) ([]common.Address, error) {
+	addrs := make([]common.Address, n)
+	for i := range addrs {
+		addr, err := w.Address(uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		addrs[i] = addr
+	}
+	return addrs, nil
+}
+
+// SignTx 用第 index 个账户的私钥签名交易
+func (w *Wallet) SignTx(index uint32, tx *common.Transaction, signer common.Signer) (*common.Transaction, error) {
+	priv, err := w.PrivateKey(index)
+	if err != nil {
+		return nil, err
+	}
+	return signer.SignTx(tx, priv)
+}
+
+// child 按 BIP-32 派生子私钥：
+// 强化派生使用 0x00||私钥，普通派生使用压缩公钥，子私钥 = (IL + 父私钥) mod N
+func (k extendedKey) child(index uint32) (extendedKey, error) {
+	data := make([]byte, 0, 37)
+	if index >= HardenedOffset {
+		data = append(data, 0)
+		data = append(data, k.key...)
+	} else {
+		priv, err := crypto.ToECDSA(k.key)
+		if err != nil {
+			return extendedKey{}, err
+		}
+		data = append(data, crypto.CompressPubkey(&priv.PublicKey)...)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	il := new(big.Int).SetBytes(sum[:32])
+	n := crypto.S256().Params().N
+	if il.Cmp(n) >= 0 {
+		return extendedKey{}, errInvalidChild
+	}
+	childKey := il.Add(il, new(big.Int).SetBytes(k.key))
+	childKey.Mod(childKey, n)
+	if childKey.Sign() == 0 {
+		return extendedKey{}, errInvalidChild
+	}
+	return extendedKey{key: childKey.FillBytes(make([]byte, 32)), chainCode: sum[32:]}, nil
+}
+
+// validPrivateKey 判断 key 是否在 [1, N) 内
+func validPrivateKey(key []byte) bool {
+	k := new(big.Int).SetBytes(key)
+	return k.Sign() > 0 && k.Cmp(crypto.S256().Params().N) < 0
+}
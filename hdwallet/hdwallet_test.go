@@ -0,0 +1,129 @@
+package hdwallet
+
+import (
+	"CHAIN/common"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testMnemonic = "test test test test test test test test test test test junk"
+
+func TestMnemonic(t *testing.T) {
+	for _, bits := range []int{128, 256} {
+		mnemonic, err := NewMnemonic(bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if words := len(strings.Fields(mnemonic)); words != bits/32*3 {
+			t.Fatalf("%d bits: got %d words", bits, words)
+		}
+		if err := ValidateMnemonic(mnemonic); err != nil {
+			t.Fatalf("generated mnemonic invalid: %v", err)
+		}
+	}
+	if _, err := NewMnemonic(100); err == nil {
+		t.Fatal("expected error for invalid entropy size")
+	}
+
+	// 校验和错误与不在词表中的词
+	for _, bad := range []string{
+		"test test test test test test test test test test test test",
+		"test test test test test test test test test test test notaword",
+	} {
+		if _, err := NewFromMnemonic(bad, ""); !errors.Is(err, ErrInvalidMnemonic) {
+			t.Fatalf("NewFromMnemonic(%q) = %v, want ErrInvalidMnemonic", bad, err)
+		}
+	}
+
+	// BIP-39 官方测试向量
+	seed, err := NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "TREZOR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04"
+	if hex.EncodeToString(seed) != want {
+		t.Fatalf("seed = %x, want %s", seed, want)
+	}
+}
+
+// BIP-32 测试向量 1
+func TestDeriveBIP32Vector(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	w, err := NewFromSeed(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"m":                      "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35",
+		"m/0'":                   "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea",
+		"m/0'/1":                 "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368",
+		"m/0'/1/2'":              "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca",
+		"m/0'/1/2'/2/1000000000": "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8",
+	}
+	for pathStr, want := range tests {
+		path, err := ParseDerivationPath(pathStr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		priv, err := w.Derive(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(crypto.FromECDSA(priv)); got != want {
+			t.Errorf("%s: key = %s, want %s", pathStr, got, want)
+		}
+	}
+}
+
+func TestWalletAddresses(t *testing.T) {
+	w, err := NewFromMnemonic(testMnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addrs, err := w.Addresses(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 与常用开发工具的默认账户一致
+	want := []string{
+		"0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+		"0x70997970C51812dc3A010C7d01b50e0d17dc79C8",
+		"0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC",
+	}
+	for i := range want {
+		if addrs[i].String() != want[i] {
+			t.Errorf("account %d = %s, want %s", i, addrs[i], want[i])
+		}
+	}
+
+	// 派生结果可用于签名，且可恢复出同一地址
+	to := common.Address{9}
+	tx := &common.Transaction{Nonce: 1, GasPrice: big.NewInt(1), GasLimit: 21000, To: &to}
+	signer := common.NewLondonSigner(big.NewInt(1337))
+	if _, err := w.SignTx(1, tx, signer); err != nil {
+		t.Fatal(err)
+	}
+	if from, err := common.Sender(signer, tx); err != nil || from != addrs[1] {
+		t.Fatalf("sender = %s, %v; want %s", from, err, addrs[1])
+	}
+}
+
+func TestParseDerivationPath(t *testing.T) {
+	path, err := ParseDerivationPath("m/44'/60'/0'/0/7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path.String() != AccountPath(7).String() {
+		t.Fatalf("path = %s, want %s", path, AccountPath(7))
+	}
+	for _, bad := range []string{"", "44'/60'", "m/x", "m/2147483648"} {
+		if _, err := ParseDerivationPath(bad); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("ParseDerivationPath(%q) = %v, want ErrInvalidPath", bad, err)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package keystore
+
+import (
+	"CHAIN/common"
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	ethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+)
+
+// scrypt 参数。Standard 用于正式账户，Light 加解密更快，适合测试
+const (
+	StandardScryptN = ethkeystore.StandardScryptN
+	StandardScryptP = ethkeystore.StandardScryptP
+	LightScryptN    = ethkeystore.LightScryptN
+	LightScryptP    = ethkeystore.LightScryptP
+)
+
+var (
+	// ErrNoMatch 表示密钥库中没有该地址的密钥文件
+	ErrNoMatch = errors.New("no key for given address")
+	// ErrLocked 表示账户未解锁，不能直接签名
+	ErrLocked = errors.New("account is locked")
+	// ErrDecrypt 表示密码错误或密钥文件损坏
+	ErrDecrypt = ethkeystore.ErrDecrypt
+	// ErrAccountAlreadyExists 表示导入的密钥已在密钥库中
+	ErrAccountAlreadyExists = errors.New("account already exists")
+)
+
+// Account 是密钥库中的一个账户及其密钥文件路径
+type Account struct {
+	Address common.Address
+	Path    string
+}
+
+// KeyStore 把私钥以 Web3 Secret Storage（v3）格式加密保存在目录中，
+// 每个账户一个 JSON 文件。解锁后的私钥只保存在内存中
+type KeyStore struct {
+	dir      string
+	scryptN  int
+	scryptP  int
+	mu       sync.RWMutex
+	unlocked map[common.Address]*ecdsa.PrivateKey
+}
+
+// NewKeyStore 打开（必要时创建）dir 下的密钥库
+func NewKeyStore(dir string, scryptN, scryptP int) (*KeyStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &KeyStore{
+		dir:      dir,
+		scryptN:  scryptN,
+		scryptP:  scryptP,
+		unlocked: make(map[common.Address]*ecdsa.PrivateKey),
+	}, nil
+}
+
+// NewAccount 生成新私钥，用 passphrase 加密后保存
+func (ks *KeyStore) NewAccount(passphrase string) (Account, error) {
+	priv, err := crypto.GenerateKey()
+	if err != nil {
+		return Account{}, err
+	}
+	return ks.storeKey(priv, passphrase)
+}
+
+// ImportECDSA 用 passphrase 加密并保存已有私钥
+func (ks *KeyStore) ImportECDSA(priv *ecdsa.PrivateKey, passphrase string) (Account, error) {
+	if _, err := ks.Find(pubkeyToAddress(priv)); err == nil {
+		return Account{}, ErrAccountAlreadyExists
+	}
+	return ks.storeKey(priv, passphrase)
+}
+
+// Import 导入其他密钥库导出的密钥文件：用 passphrase 解密，再用 newPassphrase 重新加密
+func (ks *KeyStore) Import(keyJSON []byte, passphrase, newPassphrase string) (Account, error) {
+	key, err := ethkeystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return Account{}, err
+	}
+	return ks.ImportECDSA(key.PrivateKey, newPassphrase)
+}
+
+// Export 导出账户的密钥文件，用 newPassphrase 重新加密
+func (ks *KeyStore) Export(addr common.Address, passphrase, newPassphrase string) ([]byte, error) {
+	priv, err := ks.decrypt(addr, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return encryptKey(priv, newPassphrase, ks.scryptN, ks.scryptP)
+}
+
+// Accounts 返回密钥库中的所有账户，按地址排序
+func (ks *KeyStore) Accounts() ([]Account, error) {
+	entries, err := os.ReadDir(ks.dir)
+	if err != nil {
+		return nil, err
+	}
+	var accounts []Account
+	for _, entry := range entries {
+		// 跳过子目录、隐藏文件和编辑器临时文件
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+			continue
+		}
+		path := filepath.Join(ks.dir, name)
+		addr, err := readAddress(path)
+		if err != nil {
+			continue
+		}
+		accounts = append(accounts, Account{Address: addr, Path: path})
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return bytes.Compare(accounts[i].Address[:], accounts[j].Address[:]) < 0
+	})
+	return accounts, nil
+}
+
+// Find 返回地址对应的账户，不存在时返回 ErrNoMatch
+func (ks *KeyStore) Find(addr common.Address) (Account, error) {
+	accounts, err := ks.Accounts()
+	if err != nil {
+		return Account{}, err
+	}
+	for _, account := range accounts {
+		if account.Address == addr {
+			return account, nil
+		}
+	}
+	return Account{}, fmt.Errorf("%w: %s", ErrNoMatch, addr)
+}
+
+// Unlock 解密账户私钥并保存在内存中，直到调用 Lock
+func (ks *KeyStore) Unlock(addr common.Address, passphrase string) error {
+	priv, err := ks.decrypt(addr, passphrase)
+	if err != nil {
+		return err
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if old, ok := ks.unlocked[addr]; ok {
+		zeroKey(old)
+	}
+	ks.unlocked[addr] = priv
+	return nil
+}
+
+// Lock 从内存中清除已解锁的私钥
+func (ks *KeyStore) Lock(addr common.Address) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if priv, ok := ks.unlocked[addr]; ok {
+		zeroKey(priv)
+		delete(ks.unlocked, addr)
+	}
+}
+
+// SignTx 用已解锁账户的私钥签名交易，账户未解锁时返回 ErrLocked
+func (ks *KeyStore) SignTx(addr common.Address, tx *common.Transaction, signer common.Signer) (*common.Transaction, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	priv, ok := ks.unlocked[addr]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrLocked, addr)
+	}
+	return signer.SignTx(tx, priv)
+}
+
+// SignTxWithPassphrase 临时解密私钥签名交易，不改变账户的解锁状态
+func (ks *KeyStore) SignTxWithPassphrase(addr common.Address, passphrase string, tx *common.Transaction, signer common.Signer) (*common.Transaction, error) {
+	priv, err := ks.decrypt(addr, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(priv)
+	return signer.SignTx(tx, priv)
+}
+
+// storeKey 加密私钥并以 UTC--<时间>--<地址> 命名写入密钥库目录
+func (ks *KeyStore) storeKey(priv *ecdsa.PrivateKey, passphrase string) (Account, error) {
+	keyJSON, err := encryptKey(priv, passphrase, ks.scryptN, ks.scryptP)
+	if err != nil {
+		return Account{}, err
+	}
+	addr := pubkeyToAddress(priv)
+	path := filepath.Join(ks.dir, keyFileName(addr))
+	if err := writeKeyFile(path, keyJSON); err != nil {
+		return Account{}, err
+	}
+	return Account{Address: addr, Path: path}, nil
+}
+
+// decrypt 读取并解密账户的密钥文件
+func (ks *KeyStore) decrypt(addr common.Address, passphrase string) (*ecdsa.PrivateKey, error) {
+	account, err := ks.Find(addr)
+	if err != nil {
+		return nil, err
+	}
+	keyJSON, err := os.ReadFile(account.Path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ethkeystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	// 文件内容与文件名中的地址不符说明文件被篡改
+	if common.Address(key.Address) != addr {
+		return nil, fmt.Errorf("key content mismatch: have account %x, want %x", key.Address, addr)
+	}
+	return key.PrivateKey, nil
+}
+
+func encryptKey(priv *ecdsa.PrivateKey, passphrase string, scryptN, scryptP int) ([]byte, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	key := &ethkeystore.Key{
+		Id:         id,
+		Address:    crypto.PubkeyToAddress(priv.PublicKey),
+		PrivateKey: priv,
+	}
+	return ethkeystore.EncryptKey(key, passphrase, scryptN, scryptP)
+}
+
+// readAddress 只解析密钥文件中的地址字段，不需要密码
+func readAddress(path string) (common.Address, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var keyFile struct {
+		Address string `json:"address"`
+	}
+	if err := json.Unmarshal(data, &keyFile); err != nil {
+		return common.Address{}, err
+	}
+	addr, err := common.ParseAddress(keyFile.Address)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("key file %s: %w", path, err)
+	}
+	return addr, nil
+}
+
+// writeKeyFile 先写临时文件再重命名，避免留下写了一半的密钥文件
+func writeKeyFile(path string, content []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func keyFileName(addr common.Address) string {
+	ts := time.Now().UTC().Format("2006-01-02T15-04-05.000000000Z")
+	return fmt.Sprintf("UTC--%s--%s", ts, hex.EncodeToString(addr[:]))
+}
+
+func pubkeyToAddress(priv *ecdsa.PrivateKey) common.Address {
+	return common.Address(crypto.PubkeyToAddress(priv.PublicKey))
+}
+
+// zeroKey 清除内存中的私钥
+func zeroKey(priv *ecdsa.PrivateKey) {
+	b := priv.D.Bits()
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,129 @@
+package keystore
+
+import (
+	"CHAIN/common"
+	"errors"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func newTestKeyStore(t *testing.T) *KeyStore {
+	t.Helper()
+	ks, err := NewKeyStore(t.TempDir(), LightScryptN, LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ks
+}
+
+func TestKeyStoreLifecycle(t *testing.T) {
+	ks := newTestKeyStore(t)
+	account, err := ks.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(account.Path); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("key file %s: %v, mode %v", account.Path, err, info.Mode())
+	}
+
+	accounts, err := ks.Accounts()
+	if err != nil || len(accounts) != 1 || accounts[0] != account {
+		t.Fatalf("Accounts() = %v, %v; want [%v]", accounts, err, account)
+	}
+
+	to := common.Address{9}
+	signer := common.NewLondonSigner(big.NewInt(1337))
+	newTx := func() *common.Transaction {
+		return &common.Transaction{Type: common.DynamicFeeTxType, Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), GasLimit: 21000, To: &to}
+	}
+
+	// 未解锁时不能签名
+	if _, err := ks.SignTx(account.Address, newTx(), signer); !errors.Is(err, ErrLocked) {
+		t.Fatalf("SignTx on locked account = %v, want ErrLocked", err)
+	}
+	if err := ks.Unlock(account.Address, "bar"); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("Unlock with wrong passphrase = %v, want ErrDecrypt", err)
+	}
+	if err := ks.Unlock(account.Address, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := ks.SignTx(account.Address, newTx(), signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from, err := common.Sender(signer, tx); err != nil || from != account.Address {
+		t.Fatalf("signed tx sender = %s, %v; want %s", from, err, account.Address)
+	}
+
+	ks.Lock(account.Address)
+	if _, err := ks.SignTx(account.Address, newTx(), signer); !errors.Is(err, ErrLocked) {
+		t.Fatalf("SignTx after Lock = %v, want ErrLocked", err)
+	}
+
+	// 使用密码临时签名不会解锁账户
+	tx, err = ks.SignTxWithPassphrase(account.Address, "foo", newTx(), signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from, _ := common.Sender(signer, tx); from != account.Address {
+		t.Fatalf("signed tx sender = %s, want %s", from, account.Address)
+	}
+	if _, err := ks.SignTx(account.Address, newTx(), signer); !errors.Is(err, ErrLocked) {
+		t.Fatal("SignTxWithPassphrase must not unlock the account")
+	}
+
+	if err := ks.Unlock(common.Address{1}, "foo"); !errors.Is(err, ErrNoMatch) {
+		t.Fatalf("Unlock unknown account = %v, want ErrNoMatch", err)
+	}
+}
+
+func TestKeyStoreImportExport(t *testing.T) {
+	ks := newTestKeyStore(t)
+	priv, _ := crypto.GenerateKey()
+	account, err := ks.ImportECDSA(priv, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Address != common.Address(crypto.PubkeyToAddress(priv.PublicKey)) {
+		t.Fatal("imported account address mismatch")
+	}
+	if _, err := ks.ImportECDSA(priv, "foo"); !errors.Is(err, ErrAccountAlreadyExists) {
+		t.Fatalf("duplicate import = %v, want ErrAccountAlreadyExists", err)
+	}
+
+	keyJSON, err := ks.Export(account.Address, "foo", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.Export(account.Address, "wrong", "export"); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("Export with wrong passphrase = %v, want ErrDecrypt", err)
+	}
+
+	// 导出的文件可以导入另一个密钥库，并使用新密码
+	other := newTestKeyStore(t)
+	if _, err := other.Import(keyJSON, "wrong", "new"); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("Import with wrong passphrase = %v, want ErrDecrypt", err)
+	}
+	imported, err := other.Import(keyJSON, "export", "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Address != account.Address {
+		t.Fatal("exported key imported with a different address")
+	}
+	if err := other.Unlock(imported.Address, "new"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新打开目录后账户仍然存在
+	reopened, err := NewKeyStore(other.dir, LightScryptN, LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reopened.Find(account.Address); err != nil {
+		t.Fatalf("Find after reopen: %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package boltdb
+
+import (
+	"CHAIN/kvstore"
+	"bytes"
+	"errors"
+	"sync/atomic"
+
+	bolt "go.etcd.io/bbolt"
+	berrors "go.etcd.io/bbolt/errors"
+)
+
+// 所有键值都存放在同一个 bucket 中
+var bucketName = []byte("chain")
+
+type BoltDBStore struct {
+	db     *bolt.DB
+	closed atomic.Bool
+}
+
+// NewBoltDBStore 创建并打开一个 bbolt 实例
+func NewBoltDBStore(path string) (kvstore.KVStore, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketName)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltDBStore{db: db}, nil
+}
+
+func (b *BoltDBStore) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, kvstore.ErrEmptyKey
+	}
+	var value []byte
+	err := b.view(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucketName).Get(key)
+		if v == nil {
+			return kvstore.ErrNotFound
+		}
+		// bbolt 返回的切片只在事务内有效，需要拷贝
+		value = bytes.Clone(v)
+		return nil
+	})
+	return value, err
+}
+
+func (b *BoltDBStore) Put(key, value []byte) error {
+	if len(key) == 0 {
+		return kvstore.ErrEmptyKey
+	}
+	return b.update(func(tx *bolt.Tx) error {
+		// bbolt 用 nil 表示键不存在，空值统一存为长度为 0 的切片
+		return tx.Bucket(bucketName).Put(key, append([]byte{}, value...))
+	})
+}
+
+func (b *BoltDBStore) Delete(key []byte) error {
+	if len(key) == 0 {
+		return kvstore.ErrEmptyKey
+	}
+	return b.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketName).Delete(key)
+	})
+}
+
+func (b *BoltDBStore) Has(key []byte) (bool, error) {
+	if len(key) == 0 {
+		return false, kvstore.ErrEmptyKey
+	}
+	var exists bool
+	err := b.view(func(tx *bolt.Tx) error {
+		exists = tx.Bucket(bucketName).Get(key) != nil
+		return nil
+	})
+	return exists, err
+}
+
+func (b *BoltDBStore) NewBatch() kvstore.Batch {
+	return &boltDBBatch{store: b}
+}
+
+// NewIterator 在一个只读事务内拷贝出匹配前缀的数据。
+// 不长时间持有读事务，避免迭代过程中的写操作因 mmap 扩容而阻塞。
+func (b *BoltDBStore) NewIterator(prefix []byte) kvstore.Iterator {
+	it := &boltDBIterator{index: -1}
+	it.err = b.view(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketName).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			it.keys = append(it.keys, bytes.Clone(k))
+			it.values = append(it.values, bytes.Clone(v))
+		}
+		return nil
+	})
+	return it
+}
+
+func (b *BoltDBStore) Close() error {
+	if !b.closed.CompareAndSwap(false, true) {
+		return kvstore.ErrClosed
+	}
+	return b.db.Close()
+}
+
+// view 执行只读事务，并把 bbolt 的关闭错误转换为 kvstore.ErrClosed
+func (b *BoltDBStore) view(fn func(tx *bolt.Tx) error) error {
+	return convertError(b.db.View(fn))
+}
+
+// update 执行读写事务，并把 bbolt 的关闭错误转换为 kvstore.ErrClosed
+func (b *BoltDBStore) update(fn func(tx *bolt.Tx) error) error {
+	return convertError(b.db.Update(fn))
+}
+
+func convertError(err error) error {
+	if errors.Is(err, berrors.ErrDatabaseNotOpen) {
+		return kvstore.ErrClosed
+	}
+	return err
+}
+
+// boltDBBatch 缓存写操作，Write 时在同一个读写事务中提交
+type boltDBBatch struct {
+	store *BoltDBStore
+	ops   []boltDBBatchOp
+}
+
+type boltDBBatchOp struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+func (b *boltDBBatch) Put(key, value []byte) error {
+	if len(key) == 0 {
+		return kvstore.ErrEmptyKey
+	}
+	b.ops = append(b.ops, boltDBBatchOp{key: bytes.Clone(key), value: append([]byte{}, value...)})
+	return nil
+}
+
+func (b *boltDBBatch) Delete(key []byte) error {
+	if len(key) == 0 {
+		return kvstore.ErrEmptyKey
+	}
+	b.ops = append(b.ops, boltDBBatchOp{key: bytes.Clone(key), delete: true})
+	return nil
+}
+
+func (b *boltDBBatch) Len() int {
+	return len(b.ops)
+}
+
+func (b *boltDBBatch) Write() error {
+	return b.store.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketName)
+		for _, op := range b.ops {
+			var err error
+			if op.delete {
+				err = bucket.Delete(op.key)
+			} else {
+				err = bucket.Put(op.key, op.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *boltDBBatch) Reset() {
+	b.ops = b.ops[:0]
+}
+
+type boltDBIterator struct {
+	keys   [][]byte
+	values [][]byte
+	index  int
+	err    error
+}
+
+func (it *boltDBIterator) Next() bool {
+	if it.index+1 >= len(it.keys) {
+		it.index = len(it.keys)
+		return false
+	}
+	it.index++
+	return true
+}
+
+func (it *boltDBIterator) Key() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return it.keys[it.index]
+}
+
+func (it *boltDBIterator) Value() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return it.values[it.index]
+}
+
+func (it *boltDBIterator) Error() error {
+	return it.err
+}
+
+func (it *boltDBIterator) Release() {
+	it.keys, it.values = nil, nil
+}
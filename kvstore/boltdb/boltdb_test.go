@@ -0,0 +1,19 @@
+package boltdb
+
+import (
+	"path/filepath"
+	"testing"
+
+	"CHAIN/kvstore"
+	"CHAIN/kvstore/kvstoretest"
+)
+
+func TestBoltDBStore(t *testing.T) {
+	kvstoretest.Run(t, func(t *testing.T) kvstore.KVStore {
+		db, err := NewBoltDBStore(filepath.Join(t.TempDir(), "chain.db"))
+		if err != nil {
+			t.Fatalf("Failed to open BoltDB: %v", err)
+		}
+		return db
+	})
+}
@@ -1,6 +1,18 @@
 package kvstore
 
-import "io"
+import (
+	"errors"
+	"io"
+)
+
+var (
+	// ErrEmptyKey is returned by every backend when a zero-length key is used.
+	ErrEmptyKey = errors.New("key cannot be empty")
+	// ErrNotFound is returned by Get when the key does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrClosed is returned by every operation on a closed store.
+	ErrClosed = errors.New("kvstore closed")
+)
 
 type KVStore interface {
 	// Get retrieves the value associated with the given key.
@@ -11,5 +23,39 @@ type KVStore interface {
 	Delete(key []byte) error
 	// Has checks if the key exists in the database.
 	Has(key []byte) (bool, error)
+	// NewBatch creates a write-only batch that is applied atomically on Write.
+	NewBatch() Batch
+	// NewIterator iterates over a snapshot of all keys with the given prefix
+	// in ascending key order.
+	NewIterator(prefix []byte) Iterator
 	io.Closer
 }
+
+// Batch buffers writes until Write is called.
+type Batch interface {
+	// Put queues a write of the value with the given key.
+	Put(key, value []byte) error
+	// Delete queues removal of the given key.
+	Delete(key []byte) error
+	// Len returns the number of queued operations.
+	Len() int
+	// Write applies all queued operations to the store.
+	Write() error
+	// Reset discards all queued operations so the batch can be reused.
+	Reset()
+}
+
+// Iterator walks key/value pairs in ascending key order. Key and Value are
+// only valid until the next call to Next.
+type Iterator interface {
+	// Next moves to the next pair and reports whether one exists.
+	Next() bool
+	// Key returns the key of the current pair.
+	Key() []byte
+	// Value returns the value of the current pair.
+	Value() []byte
+	// Error returns any error encountered during iteration.
+	Error() error
+	// Release frees the resources held by the iterator.
+	Release()
+}
@@ -0,0 +1,331 @@
+// Package kvstoretest 提供所有 kvstore.KVStore 后端共用的一致性测试套件。
+package kvstoretest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"CHAIN/kvstore"
+)
+
+// Factory 为每个子测试创建一个全新的空存储。
+// 磁盘后端应使用 t.TempDir() 作为数据目录。
+type Factory func(t *testing.T) kvstore.KVStore
+
+// Run 对 factory 创建的存储执行全部一致性测试
+func Run(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, db kvstore.KVStore)
+	}{
+		{"EmptyKey", testEmptyKey},
+		{"MissingKey", testMissingKey},
+		{"PutGet", testPutGet},
+		{"Overwrite", testOverwrite},
+		{"Delete", testDelete},
+		{"Has", testHas},
+		{"ValueIsCopied", testValueIsCopied},
+		{"Batch", testBatch},
+		{"BatchReset", testBatchReset},
+		{"Iterator", testIterator},
+		{"IteratorSnapshot", testIteratorSnapshot},
+		{"Concurrent", testConcurrent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := factory(t)
+			defer db.Close()
+			tt.fn(t, db)
+		})
+	}
+	t.Run("Close", func(t *testing.T) {
+		testClose(t, factory(t))
+	})
+}
+
+func testEmptyKey(t *testing.T, db kvstore.KVStore) {
+	if err := db.Put(nil, []byte("v")); !errors.Is(err, kvstore.ErrEmptyKey) {
+		t.Errorf("Put with empty key = %v, want ErrEmptyKey", err)
+	}
+	if _, err := db.Get([]byte{}); !errors.Is(err, kvstore.ErrEmptyKey) {
+		t.Errorf("Get with empty key = %v, want ErrEmptyKey", err)
+	}
+	if _, err := db.Has(nil); !errors.Is(err, kvstore.ErrEmptyKey) {
+		t.Errorf("Has with empty key = %v, want ErrEmptyKey", err)
+	}
+	if err := db.Delete(nil); !errors.Is(err, kvstore.ErrEmptyKey) {
+		t.Errorf("Delete with empty key = %v, want ErrEmptyKey", err)
+	}
+	batch := db.NewBatch()
+	if err := batch.Put(nil, []byte("v")); !errors.Is(err, kvstore.ErrEmptyKey) {
+		t.Errorf("Batch.Put with empty key = %v, want ErrEmptyKey", err)
+	}
+	if err := batch.Delete(nil); !errors.Is(err, kvstore.ErrEmptyKey) {
+		t.Errorf("Batch.Delete with empty key = %v, want ErrEmptyKey", err)
+	}
+}
+
+func testMissingKey(t *testing.T, db kvstore.KVStore) {
+	val, err := db.Get([]byte("missing"))
+	if !errors.Is(err, kvstore.ErrNotFound) {
+		t.Fatalf("Get of missing key = %q, %v; want ErrNotFound", val, err)
+	}
+	has, err := db.Has([]byte("missing"))
+	if err != nil || has {
+		t.Fatalf("Has of missing key = %v, %v; want false, nil", has, err)
+	}
+	// 删除不存在的键不是错误
+	if err := db.Delete([]byte("missing")); err != nil {
+		t.Fatalf("Delete of missing key failed: %v", err)
+	}
+}
+
+func testPutGet(t *testing.T, db kvstore.KVStore) {
+	mustPut(t, db, "foo", "bar")
+	mustGet(t, db, "foo", "bar")
+
+	// 空值可以存储，且与“不存在”区分开
+	if err := db.Put([]byte("empty"), nil); err != nil {
+		t.Fatalf("Put empty value failed: %v", err)
+	}
+	val, err := db.Get([]byte("empty"))
+	if err != nil || len(val) != 0 {
+		t.Fatalf("Get empty value = %q, %v; want empty, nil", val, err)
+	}
+	if has, _ := db.Has([]byte("empty")); !has {
+		t.Fatal("Has should report key with empty value")
+	}
+}
+
+func testOverwrite(t *testing.T, db kvstore.KVStore) {
+	mustPut(t, db, "foo", "bar")
+	mustPut(t, db, "foo", "baz")
+	mustGet(t, db, "foo", "baz")
+}
+
+func testDelete(t *testing.T, db kvstore.KVStore) {
+	mustPut(t, db, "foo", "bar")
+	if err := db.Delete([]byte("foo")); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := db.Get([]byte("foo")); !errors.Is(err, kvstore.ErrNotFound) {
+		t.Fatalf("Get after Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func testHas(t *testing.T, db kvstore.KVStore) {
+	mustPut(t, db, "foo", "bar")
+	has, err := db.Has([]byte("foo"))
+	if err != nil || !has {
+		t.Fatalf("Has = %v, %v; want true, nil", has, err)
+	}
+	has, err = db.Has([]byte("fo"))
+	if err != nil || has {
+		t.Fatalf("Has of prefix = %v, %v; want false, nil", has, err)
+	}
+}
+
+func testValueIsCopied(t *testing.T, db kvstore.KVStore) {
+	value := []byte("bar")
+	if err := db.Put([]byte("foo"), value); err != nil {
+		t.Fatal(err)
+	}
+	value[0] = 'X'
+	mustGet(t, db, "foo", "bar")
+
+	got, _ := db.Get([]byte("foo"))
+	got[0] = 'Y'
+	mustGet(t, db, "foo", "bar")
+}
+
+func testBatch(t *testing.T, db kvstore.KVStore) {
+	mustPut(t, db, "stale", "1")
+
+	batch := db.NewBatch()
+	for i := 0; i < 10; i++ {
+		if err := batch.Put([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("val%d", i))); err != nil {
+			t.Fatalf("Batch.Put failed: %v", err)
+		}
+	}
+	if err := batch.Delete([]byte("stale")); err != nil {
+		t.Fatalf("Batch.Delete failed: %v", err)
+	}
+	if batch.Len() != 11 {
+		t.Fatalf("Batch.Len = %d, want 11", batch.Len())
+	}
+
+	// Write 之前不可见
+	if has, _ := db.Has([]byte("key0")); has {
+		t.Fatal("batch write visible before Write")
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("Batch.Write failed: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		mustGet(t, db, fmt.Sprintf("key%d", i), fmt.Sprintf("val%d", i))
+	}
+	if has, _ := db.Has([]byte("stale")); has {
+		t.Fatal("batch delete not applied")
+	}
+}
+
+func testBatchReset(t *testing.T, db kvstore.KVStore) {
+	batch := db.NewBatch()
+	batch.Put([]byte("dropped"), []byte("1"))
+	batch.Reset()
+	if batch.Len() != 0 {
+		t.Fatalf("Batch.Len after Reset = %d, want 0", batch.Len())
+	}
+	batch.Put([]byte("kept"), []byte("2"))
+	if err := batch.Write(); err != nil {
+		t.Fatalf("Batch.Write failed: %v", err)
+	}
+	if has, _ := db.Has([]byte("dropped")); has {
+		t.Fatal("reset operation was written")
+	}
+	mustGet(t, db, "kept", "2")
+}
+
+func testIterator(t *testing.T, db kvstore.KVStore) {
+	for _, k := range []string{"b2", "a1", "b1", "c1", "b3"} {
+		mustPut(t, db, k, "v"+k)
+	}
+
+	check := func(prefix string, want []string) {
+		t.Helper()
+		it := db.NewIterator([]byte(prefix))
+		defer it.Release()
+		var got []string
+		for it.Next() {
+			key := string(it.Key())
+			if string(it.Value()) != "v"+key {
+				t.Errorf("iterator value for %s = %q", key, it.Value())
+			}
+			got = append(got, key)
+		}
+		if err := it.Error(); err != nil {
+			t.Fatalf("iterator error: %v", err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("iterate prefix %q = %v, want %v", prefix, got, want)
+		}
+	}
+	check("", []string{"a1", "b1", "b2", "b3", "c1"})
+	check("b", []string{"b1", "b2", "b3"})
+	check("d", nil)
+}
+
+func testIteratorSnapshot(t *testing.T, db kvstore.KVStore) {
+	mustPut(t, db, "k1", "v1")
+	it := db.NewIterator(nil)
+	defer it.Release()
+	mustPut(t, db, "k2", "v2")
+	mustPut(t, db, "k1", "changed")
+
+	var pairs []string
+	for it.Next() {
+		pairs = append(pairs, string(it.Key())+"="+string(it.Value()))
+	}
+	if fmt.Sprint(pairs) != "[k1=v1]" {
+		t.Fatalf("iterator saw %v, want snapshot [k1=v1]", pairs)
+	}
+}
+
+func testConcurrent(t *testing.T, db kvstore.KVStore) {
+	const workers, perWorker = 8, 50
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				key := []byte(fmt.Sprintf("w%d-%d", w, i))
+				if err := db.Put(key, key); err != nil {
+					errs <- err
+					return
+				}
+				val, err := db.Get(key)
+				if err != nil || !bytes.Equal(val, key) {
+					errs <- fmt.Errorf("get %s = %q, %v", key, val, err)
+					return
+				}
+				if _, err := db.Has(key); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	it := db.NewIterator([]byte("w"))
+	defer it.Release()
+	count := 0
+	for it.Next() {
+		count++
+	}
+	if count != workers*perWorker {
+		t.Fatalf("iterated %d keys, want %d", count, workers*perWorker)
+	}
+}
+
+func testClose(t *testing.T, db kvstore.KVStore) {
+	mustPut(t, db, "foo", "bar")
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := db.Get([]byte("foo")); !errors.Is(err, kvstore.ErrClosed) {
+		t.Errorf("Get after Close = %v, want ErrClosed", err)
+	}
+	if err := db.Put([]byte("foo"), []byte("bar")); !errors.Is(err, kvstore.ErrClosed) {
+		t.Errorf("Put after Close = %v, want ErrClosed", err)
+	}
+	if _, err := db.Has([]byte("foo")); !errors.Is(err, kvstore.ErrClosed) {
+		t.Errorf("Has after Close = %v, want ErrClosed", err)
+	}
+	if err := db.Delete([]byte("foo")); !errors.Is(err, kvstore.ErrClosed) {
+		t.Errorf("Delete after Close = %v, want ErrClosed", err)
+	}
+	batch := db.NewBatch()
+	batch.Put([]byte("foo"), []byte("bar"))
+	if err := batch.Write(); !errors.Is(err, kvstore.ErrClosed) {
+		t.Errorf("Batch.Write after Close = %v, want ErrClosed", err)
+	}
+	it := db.NewIterator(nil)
+	if it.Next() {
+		t.Error("iterator over closed store should be empty")
+	}
+	if err := it.Error(); !errors.Is(err, kvstore.ErrClosed) {
+		t.Errorf("iterator over closed store error = %v, want ErrClosed", err)
+	}
+	it.Release()
+	if err := db.Close(); !errors.Is(err, kvstore.ErrClosed) {
+		t.Errorf("second Close = %v, want ErrClosed", err)
+	}
+}
+
+func mustPut(t *testing.T, db kvstore.KVStore, key, value string) {
+	t.Helper()
+	if err := db.Put([]byte(key), []byte(value)); err != nil {
+		t.Fatalf("Put(%s) failed: %v", key, err)
+	}
+}
+
+func mustGet(t *testing.T, db kvstore.KVStore, key, want string) {
+	t.Helper()
+	val, err := db.Get([]byte(key))
+	if err != nil {
+		t.Fatalf("Get(%s) failed: %v", key, err)
+	}
+	if string(val) != want {
+		t.Fatalf("Get(%s) = %q, want %q", key, val, want)
+	}
+}
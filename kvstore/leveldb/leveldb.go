@@ -3,6 +3,8 @@ package leveldb
 import (
 	"CHAIN/kvstore"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 type LevelDBStore struct {
@@ -19,21 +21,99 @@ func NewLevelDBStore(path string) (kvstore.KVStore, error) {
 }
 
 func (l *LevelDBStore) Get(key []byte) ([]byte, error) {
-	return l.db.Get(key, nil)
+	if len(key) == 0 {
+		return nil, kvstore.ErrEmptyKey
+	}
+	value, err := l.db.Get(key, nil)
+	return value, convertError(err)
 }
 
 func (l *LevelDBStore) Put(key, value []byte) error {
-	return l.db.Put(key, value, nil)
+	if len(key) == 0 {
+		return kvstore.ErrEmptyKey
+	}
+	return convertError(l.db.Put(key, value, nil))
 }
 
 func (l *LevelDBStore) Delete(key []byte) error {
-	return l.db.Delete(key, nil)
+	if len(key) == 0 {
+		return kvstore.ErrEmptyKey
+	}
+	return convertError(l.db.Delete(key, nil))
 }
 
 func (l *LevelDBStore) Has(key []byte) (bool, error) {
-	return l.db.Has(key, nil)
+	if len(key) == 0 {
+		return false, kvstore.ErrEmptyKey
+	}
+	has, err := l.db.Has(key, nil)
+	return has, convertError(err)
+}
+
+func (l *LevelDBStore) NewBatch() kvstore.Batch {
+	return &levelDBBatch{db: l.db, batch: new(leveldb.Batch)}
+}
+
+// NewIterator LevelDB 的迭代器本身就基于隐式快照
+func (l *LevelDBStore) NewIterator(prefix []byte) kvstore.Iterator {
+	return &levelDBIterator{l.db.NewIterator(util.BytesPrefix(prefix), nil)}
 }
 
 func (l *LevelDBStore) Close() error {
-	return l.db.Close()
+	return convertError(l.db.Close())
+}
+
+// convertError 将 goleveldb 的错误转换为 kvstore 的通用错误
+func convertError(err error) error {
+	switch err {
+	case leveldb.ErrNotFound:
+		return kvstore.ErrNotFound
+	case leveldb.ErrClosed:
+		return kvstore.ErrClosed
+	}
+	return err
+}
+
+type levelDBBatch struct {
+	db    *leveldb.DB
+	batch *leveldb.Batch
+}
+
+func (b *levelDBBatch) Put(key, value []byte) error {
+	if len(key) == 0 {
+		return kvstore.ErrEmptyKey
+	}
+	b.batch.Put(key, value)
+	return nil
+}
+
+func (b *levelDBBatch) Delete(key []byte) error {
+	if len(key) == 0 {
+		return kvstore.ErrEmptyKey
+	}
+	b.batch.Delete(key)
+	return nil
+}
+
+func (b *levelDBBatch) Len() int {
+	return b.batch.Len()
 }
+
+func (b *levelDBBatch) Write() error {
+	return convertError(b.db.Write(b.batch, nil))
+}
+
+func (b *levelDBBatch) Reset() {
+	b.batch.Reset()
+}
+
+// levelDBIterator 适配 goleveldb 迭代器，Error 与 kvstore.Iterator 命名保持一致
+type levelDBIterator struct {
+	iter iterator.Iterator
+}
+
+func (it *levelDBIterator) Next() bool    { return it.iter.Next() }
+func (it *levelDBIterator) Key() []byte   { return it.iter.Key() }
+func (it *levelDBIterator) Value() []byte { return it.iter.Value() }
+func (it *levelDBIterator) Error() error  { return convertError(it.iter.Error()) }
+func (it *levelDBIterator) Release()      { it.iter.Release() }
@@ -1,7 +1,8 @@
 package kvstore
 
 import (
-	"errors"
+	"bytes"
+	"sort"
 	"sync"
 )
 
@@ -20,55 +21,188 @@ func NewMemoryKVStore() KVStore {
 
 func (m *MemoryKVStore) Put(key []byte, value []byte) error {
 	if len(key) == 0 {
-		return errors.New("key cannot be empty")
+		return ErrEmptyKey
 	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.data[string(key)] = value
+	if m.data == nil {
+		return ErrClosed
+	}
+	m.data[string(key)] = bytes.Clone(value)
 	return nil
 }
 
 func (m *MemoryKVStore) Get(key []byte) ([]byte, error) {
 	if len(key) == 0 {
-		return nil, errors.New("key cannot be empty")
+		return nil, ErrEmptyKey
 	}
 
 	m.mu.RLock()
 	defer m.mu.RUnlock()
+	if m.data == nil {
+		return nil, ErrClosed
+	}
 	value, exists := m.data[string(key)]
 	if !exists {
-		return nil, errors.New("key not found")
+		return nil, ErrNotFound
 	}
-	return value, nil
+	return bytes.Clone(value), nil
 }
 
 func (m *MemoryKVStore) Delete(key []byte) error {
 	if len(key) == 0 {
-		return errors.New("key cannot be empty")
+		return ErrEmptyKey
 	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.data == nil {
+		return ErrClosed
+	}
 	delete(m.data, string(key))
 	return nil
 }
 
 func (m *MemoryKVStore) Has(key []byte) (bool, error) {
 	if len(key) == 0 {
-		return false, errors.New("key cannot be empty")
+		return false, ErrEmptyKey
 	}
 
 	m.mu.RLock()
 	defer m.mu.RUnlock()
+	if m.data == nil {
+		return false, ErrClosed
+	}
 	_, exists := m.data[string(key)]
 	return exists, nil
 }
 
+func (m *MemoryKVStore) NewBatch() Batch {
+	return &memoryBatch{store: m}
+}
+
+// NewIterator 在创建时对匹配前缀的数据做快照，之后的写入不影响迭代结果
+func (m *MemoryKVStore) NewIterator(prefix []byte) Iterator {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.data == nil {
+		return &memoryIterator{index: -1, err: ErrClosed}
+	}
+
+	var keys []string
+	for key := range m.data {
+		if bytes.HasPrefix([]byte(key), prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i] = bytes.Clone(m.data[key])
+	}
+	return &memoryIterator{keys: keys, values: values, index: -1}
+}
+
 func (m *MemoryKVStore) Close() error {
 	// 清空数据
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.data == nil {
+		return ErrClosed
+	}
 	m.data = nil
 	return nil
 }
+
+// memoryBatch 缓存写操作，Write 时在同一把锁内一次性应用
+type memoryBatch struct {
+	store *MemoryKVStore
+	ops   []memoryBatchOp
+}
+
+type memoryBatchOp struct {
+	key    string
+	value  []byte
+	delete bool
+}
+
+func (b *memoryBatch) Put(key, value []byte) error {
+	if len(key) == 0 {
+		return ErrEmptyKey
+	}
+	b.ops = append(b.ops, memoryBatchOp{key: string(key), value: bytes.Clone(value)})
+	return nil
+}
+
+func (b *memoryBatch) Delete(key []byte) error {
+	if len(key) == 0 {
+		return ErrEmptyKey
+	}
+	b.ops = append(b.ops, memoryBatchOp{key: string(key), delete: true})
+	return nil
+}
+
+func (b *memoryBatch) Len() int {
+	return len(b.ops)
+}
+
+func (b *memoryBatch) Write() error {
+	b.store.mu.Lock()
+	defer b.store.mu.Unlock()
+	if b.store.data == nil {
+		return ErrClosed
+	}
+	for _, op := range b.ops {
+		if op.delete {
+			delete(b.store.data, op.key)
+		} else {
+			b.store.data[op.key] = op.value
+		}
+	}
+	return nil
+}
+
+func (b *memoryBatch) Reset() {
+	b.ops = b.ops[:0]
+}
+
+// memoryIterator 遍历创建时的快照
+type memoryIterator struct {
+	keys   []string
+	values [][]byte
+	index  int
+	err    error
+}
+
+func (it *memoryIterator) Next() bool {
+	if it.index+1 >= len(it.keys) {
+		it.index = len(it.keys)
+		return false
+	}
+	it.index++
+	return true
+}
+
+func (it *memoryIterator) Key() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return []byte(it.keys[it.index])
+}
+
+func (it *memoryIterator) Value() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return it.values[it.index]
+}
+
+func (it *memoryIterator) Error() error {
+	return it.err
+}
+
+func (it *memoryIterator) Release() {
+	it.keys, it.values = nil, nil
+}
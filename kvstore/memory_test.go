@@ -0,0 +1,14 @@
+package kvstore_test
+
+import (
+	"testing"
+
+	"CHAIN/kvstore"
+	"CHAIN/kvstore/kvstoretest"
+)
+
+func TestMemoryKVStore(t *testing.T) {
+	kvstoretest.Run(t, func(t *testing.T) kvstore.KVStore {
+		return kvstore.NewMemoryKVStore()
+	})
+}
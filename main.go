@@ -3,94 +3,158 @@ package main
 import (
 	"CHAIN/BlockChain"
 	"CHAIN/common"
+	"CHAIN/hdwallet"
+	"CHAIN/node"
+	"CHAIN/params"
 	"CHAIN/statedb"
 	"CHAIN/txpool"
+	"flag"
 	"fmt"
 	"math/big"
+	"os"
 )
 
+// devMnemonic 是本地开发使用的公开助记词，切勿用于真实资产
+const devMnemonic = "test test test test test test test test test test test junk"
+
 func main() {
+	cfg := node.DefaultConfig()
+	flag.StringVar(&cfg.DataDir, "datadir", cfg.DataDir, "数据目录")
+	flag.StringVar(&cfg.DBBackend, "db.backend", cfg.DBBackend, "存储后端 (memory|leveldb|boltdb)")
+	genesisPath := flag.String("genesis", "", "创世配置文件（JSON），为空时使用默认配置")
+	mnemonic := flag.String("mnemonic", devMnemonic, "派生演示账户的 BIP-39 助记词")
+	flag.Parse()
+
 	fmt.Println("🚀 启动简易区块链...")
 
+	db, err := node.OpenDatabase(cfg)
+	if err != nil {
+		fmt.Println("❌ 打开数据库失败：", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+	fmt.Println("💾 存储后端：", cfg.DBBackend)
+
+	// 演示账户 A、B 由助记词按 m/44'/60'/0'/0/i 派生
+	wallet, err := hdwallet.NewFromMnemonic(*mnemonic, "")
+	if err != nil {
+		fmt.Println("❌ 助记词无效：", err)
+		os.Exit(1)
+	}
+	addrs, err := wallet.Addresses(2)
+	if err != nil {
+		fmt.Println("❌ 派生账户失败：", err)
+		os.Exit(1)
+	}
+	addrA, addrB := addrs[0], addrs[1]
+
+	// 初始化创世区块，默认配置下给演示账户预置余额
+	spec := BlockChain.DefaultGenesis()
+	spec.Fund(new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18)), addrs...)
+	if *genesisPath != "" {
+		if spec, err = BlockChain.LoadGenesis(*genesisPath); err != nil {
+			fmt.Println("❌ 读取创世配置失败：", err)
+			os.Exit(1)
+		}
+	}
+	genesis, err := BlockChain.SetupGenesisBlock(db, spec)
+	if err != nil {
+		fmt.Println("❌ 初始化创世区块失败：", err)
+		os.Exit(1)
+	}
+	fmt.Println("🔗 链 ID：", spec.Config.ChainID)
+
 	// 初始化状态数据库
-	stateDB := statedb.NewInMemoryStateDB()
-
-	addrA := common.Address{1, 2, 3}
-	addrB := common.Address{4, 5, 6}
-
-	// 初始化账户
-	stateDB.Store(addrA, &common.Account{
-		Address: addrA,
-		Balance: big.NewInt(1000),
-		Nonce:   0,
-	})
-	stateDB.Store(addrB, &common.Account{
-		Address: addrB,
-		Balance: big.NewInt(0),
-		Nonce:   0,
-	})
+	stateDB, err := statedb.New(genesis.StateRoot, db)
+	if err != nil {
+		fmt.Println("❌ 打开状态数据库失败：", err)
+		os.Exit(1)
+	}
+
+	// 初始化区块链（创世块）
+	chain := BlockChain.NewChain(db, spec.Config, genesis)
+	signer := common.MakeSigner(spec.Config, genesis.Index+1)
 
 	// 初始化交易池
 	pool := txpool.NewDefaultPool(nil)
 	pool.State = stateDB
+	pool.Chain = chain
+	pool.ChainConfig = spec.Config
+	pool.Signer = signer
+	pool.SetGasLimit(chain.CurrentBlock().GasLimit)
 
-	// 创建交易 1（nonce = 1）
-	tx1 := &common.Transaction{
-		Fro:      addrA,
-		To:       &addrB,
-		Value:    big.NewInt(100),
-		GasLimit: 21000,
-		GasPrice: big.NewInt(1),
-		Nonce:    1,
-		R:        big.NewInt(1),
-		S:        big.NewInt(2),
-		V:        27,
-		Input:    []byte{},
-	}
-	pool.NewTx(tx1)
-
-	// 创建交易 2（nonce = 2）
-	tx2 := &common.Transaction{
-		Fro:      addrA,
-		To:       &addrB,
-		Value:    big.NewInt(200),
-		GasLimit: 21000,
-		GasPrice: big.NewInt(2),
-		Nonce:    2,
-		R:        big.NewInt(3),
-		S:        big.NewInt(4),
-		V:        28,
-		Input:    []byte("data"),
+	// 账户 A 向 B 发起两笔转账（nonce 1、2）
+	for i, value := range []int64{100, 200} {
+		tx := &common.Transaction{
+			Type:      common.DynamicFeeTxType,
+			To:        &addrB,
+			Value:     big.NewInt(value),
+			GasLimit:  30000,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(2 * int64(params.InitialBaseFee)),
+			Nonce:     uint64(i + 1),
+			Input:     []byte("data"),
+		}
+		if _, err := wallet.SignTx(0, tx, signer); err != nil {
+			fmt.Println("❌ 签名交易失败：", err)
+			os.Exit(1)
+		}
+		if err := pool.NewTx(tx); err != nil {
+			fmt.Println("❌ 交易未进入交易池：", err)
+		}
 	}
-	pool.NewTx(tx2)
 
-	// 初始化区块链（创世块）
-	var chain []*BlockChain.Block
-	genesis := BlockChain.NewBlock(nil, nil, 0)
-	chain = append(chain, genesis)
+	// 按父区块确定新区块的 Gas 上限与基础费用
+	prev := chain.CurrentBlock()
+	block := BlockChain.NewBlock(nil, prev.Hash, prev.Index+1)
+	block.GasLimit = prev.GasLimit
+	if spec.Config.IsLondon(block.Index) {
+		block.BaseFee = BlockChain.CalcBaseFee(spec.Config, prev)
+	}
+	pool.SetBaseFee(block.BaseFee)
 
 	// 从交易池获取所有待打包交易
-	var txs []*common.Transaction
 	for {
 		t := pool.Pop()
 		if t == nil {
 			break
 		}
-		txs = append(txs, t)
-		// 应用交易结果（简单模拟转账逻辑）
-		_ = stateDB.SubBalance(t.Fro, t.Value)
-		stateDB.AddBalance(*t.To, t.Value)
-		stateDB.SetNonce(t.Fro, stateDB.GetNonce(t.Fro)+1)
+		if t.GasLimit > block.GasLimit-block.GasUsed {
+			continue
+		}
+		// 应用交易结果（简单转账），失败的交易已回滚，不打包进区块
+		gas, err := BlockChain.ApplyTransaction(stateDB, signer, block, t)
+		if err != nil {
+			fmt.Println("⚠️ 交易执行失败，已回滚：", err)
+			continue
+		}
+		block.GasUsed += gas
+		block.Transactions = append(block.Transactions, t)
+	}
+
+	// 提交状态，得到新的状态根
+	stateRoot, err := stateDB.Commit()
+	if err != nil {
+		fmt.Println("❌ 提交状态失败：", err)
+		os.Exit(1)
 	}
 
-	// 打包新区块
-	prev := chain[len(chain)-1]
-	block := BlockChain.NewBlock(txs, prev.Hash, prev.Index+1)
-	chain = append(chain, block)
+	// 打包新区块并导入链
+	block.StateRoot = stateRoot
+	block.Hash = block.CalculateHash()
+	if err := chain.InsertBlock(block); err != nil {
+		fmt.Println("❌ 导入区块失败：", err)
+		os.Exit(1)
+	}
+	// 新区块已打包的交易从交易池中移除
+	if err := pool.Reset(prev, block); err != nil {
+		fmt.Println("⚠️ 重置交易池失败：", err)
+	}
 
 	fmt.Println("✅ 区块链当前高度：", block.Index)
 	fmt.Println("🧾 当前区块交易数量：", len(block.Transactions))
-	fmt.Println("📦 当前链长度：", len(chain))
+	fmt.Println("📦 当前链长度：", chain.Len())
+	fmt.Println("🌳 状态根：", stateRoot.Hex())
 
 	// 输出账户状态
 	fmt.Println("账户 A 余额:", stateDB.GetBalance(addrA))
@@ -0,0 +1,48 @@
+package node
+
+import (
+	"CHAIN/kvstore"
+	"CHAIN/kvstore/boltdb"
+	"CHAIN/kvstore/leveldb"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// 支持的存储后端名称
+const (
+	BackendMemory  = "memory"
+	BackendLevelDB = "leveldb"
+	BackendBoltDB  = "boltdb"
+)
+
+// Config 节点配置
+type Config struct {
+	DataDir   string // 数据目录，内存后端时忽略
+	DBBackend string // 键值存储后端：memory / leveldb / boltdb
+}
+
+// DefaultConfig 返回默认节点配置
+func DefaultConfig() *Config {
+	return &Config{
+		DataDir:   "chaindata",
+		DBBackend: BackendMemory,
+	}
+}
+
+// OpenDatabase 根据配置中的后端名称打开键值存储
+func OpenDatabase(cfg *Config) (kvstore.KVStore, error) {
+	switch cfg.DBBackend {
+	case BackendMemory:
+		return kvstore.NewMemoryKVStore(), nil
+	case BackendLevelDB:
+		return leveldb.NewLevelDBStore(filepath.Join(cfg.DataDir, "leveldb"))
+	case BackendBoltDB:
+		if err := os.MkdirAll(cfg.DataDir, 0700); err != nil {
+			return nil, err
+		}
+		return boltdb.NewBoltDBStore(filepath.Join(cfg.DataDir, "chain.db"))
+	default:
+		return nil, fmt.Errorf("unknown database backend: %q", cfg.DBBackend)
+	}
+}
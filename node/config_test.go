@@ -0,0 +1,30 @@
+package node
+
+import "testing"
+
+func TestOpenDatabaseBackends(t *testing.T) {
+	for _, backend := range []string{BackendMemory, BackendLevelDB, BackendBoltDB} {
+		cfg := &Config{DataDir: t.TempDir(), DBBackend: backend}
+		db, err := OpenDatabase(cfg)
+		if err != nil {
+			t.Fatalf("%s: open failed: %v", backend, err)
+		}
+		if err := db.Put([]byte("foo"), []byte("bar")); err != nil {
+			t.Fatalf("%s: Put failed: %v", backend, err)
+		}
+		val, err := db.Get([]byte("foo"))
+		if err != nil || string(val) != "bar" {
+			t.Fatalf("%s: Get returned %q, %v", backend, val, err)
+		}
+		if err := db.Close(); err != nil {
+			t.Errorf("%s: Close failed: %v", backend, err)
+		}
+	}
+}
+
+func TestOpenDatabaseUnknownBackend(t *testing.T) {
+	cfg := &Config{DataDir: t.TempDir(), DBBackend: "rocksdb"}
+	if _, err := OpenDatabase(cfg); err == nil {
+		t.Fatal("expected error for unknown backend")
+	}
+}
@@ -0,0 +1,43 @@
+package params
+
+import "math/big"
+
+// ChainConfig 是链的核心配置：链 ID 以及各分叉的激活高度。
+// 分叉高度为 nil 表示该分叉未激活。
+type ChainConfig struct {
+	ChainID     *big.Int `json:"chainId"`
+	EIP155Block *big.Int `json:"eip155Block,omitempty"` // 启用 EIP-155 重放保护的高度
+	BerlinBlock *big.Int `json:"berlinBlock,omitempty"` // 启用 EIP-2930 访问列表交易的高度
+	LondonBlock *big.Int `json:"londonBlock,omitempty"` // 启用 EIP-1559 基础费用的高度
+}
+
+// DefaultChainConfig 本地开发链的默认配置，从创世区块起启用全部分叉
+var DefaultChainConfig = &ChainConfig{
+	ChainID:     big.NewInt(1337),
+	EIP155Block: big.NewInt(0),
+	BerlinBlock: big.NewInt(0),
+	LondonBlock: big.NewInt(0),
+}
+
+// IsEIP155 判断指定高度是否已启用 EIP-155
+func (c *ChainConfig) IsEIP155(num uint64) bool {
+	return isForked(c.EIP155Block, num)
+}
+
+// IsBerlin 判断指定高度是否已启用 EIP-2930
+func (c *ChainConfig) IsBerlin(num uint64) bool {
+	return isForked(c.BerlinBlock, num)
+}
+
+// IsLondon 判断指定高度是否已启用 EIP-1559
+func (c *ChainConfig) IsLondon(num uint64) bool {
+	return isForked(c.LondonBlock, num)
+}
+
+// isForked 判断激活高度为 s 的分叉在高度 num 是否已激活
+func isForked(s *big.Int, num uint64) bool {
+	if s == nil {
+		return false
+	}
+	return s.Cmp(new(big.Int).SetUint64(num)) <= 0
+}
@@ -0,0 +1,29 @@
+package params
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestIsEIP155(t *testing.T) {
+	config := &ChainConfig{ChainID: big.NewInt(1), EIP155Block: big.NewInt(10)}
+	if config.IsEIP155(9) {
+		t.Error("EIP-155 active before fork block")
+	}
+	if !config.IsEIP155(10) || !config.IsEIP155(11) {
+		t.Error("EIP-155 inactive at or after fork block")
+	}
+	if (&ChainConfig{ChainID: big.NewInt(1)}).IsEIP155(100) {
+		t.Error("EIP-155 active without fork block")
+	}
+}
+
+func TestIsLondon(t *testing.T) {
+	config := &ChainConfig{ChainID: big.NewInt(1), LondonBlock: big.NewInt(3)}
+	if config.IsLondon(2) || !config.IsLondon(3) {
+		t.Error("London activation mismatch")
+	}
+	if !DefaultChainConfig.IsLondon(0) {
+		t.Error("default config should enable London at genesis")
+	}
+}
@@ -0,0 +1,15 @@
+package params
+
+// Gas 相关的协议常量，取值与以太坊一致
+const (
+	TxGas            uint64 = 21000 // 每笔交易的基础 Gas
+	TxDataZeroGas    uint64 = 4     // 交易数据中每个零字节的 Gas
+	TxDataNonZeroGas uint64 = 16    // 交易数据中每个非零字节的 Gas
+
+	TxAccessListAddressGas    uint64 = 2400 // 访问列表中每个地址的 Gas
+	TxAccessListStorageKeyGas uint64 = 1900 // 访问列表中每个存储槽的 Gas
+
+	BaseFeeChangeDenominator uint64 = 8          // 区块间基础费用的最大变化比例为 1/8
+	ElasticityMultiplier     uint64 = 2          // 区块 Gas 上限与目标 Gas 的比值
+	InitialBaseFee           uint64 = 1000000000 // London 首个区块的基础费用
+)
@@ -0,0 +1,157 @@
+package statedb
+
+import (
+	"CHAIN/common"
+	"CHAIN/kvstore"
+	trie "CHAIN/trie/mpt"
+	"math/big"
+)
+
+// DiffKind 表示账户变化的类型
+type DiffKind int
+
+const (
+	AccountCreated DiffKind = iota // 账户在旧状态中不存在
+	AccountDeleted                 // 账户在新状态中不存在，如被 DeleteAccount 删除
+	AccountModified
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case AccountCreated:
+		return "created"
+	case AccountDeleted:
+		return "deleted"
+	default:
+		return "modified"
+	}
+}
+
+// AccountDiff 描述一个账户在两个状态根之间的变化。
+// 账户不存在的一侧余额为 0、nonce 为 0、代码哈希为空哈希。
+type AccountDiff struct {
+	Address        common.Address
+	Kind           DiffKind
+	BalanceBefore  *big.Int
+	BalanceAfter   *big.Int
+	NonceBefore    uint64
+	NonceAfter     uint64
+	CodeHashBefore common.Hash
+	CodeHashAfter  common.Hash
+	Storage        []StorageDiff // 按键升序
+}
+
+// StorageDiff 描述一个存储槽的变化，Before / After 为空字符串表示该侧不存在
+type StorageDiff struct {
+	Key    string
+	Before string
+	After  string
+}
+
+// StateDiff 比较 db 中两个状态根，返回所有发生变化的账户（按地址升序）。
+// 两棵状态树并行遍历，哈希相同的子树直接跳过。
+func StateDiff(db kvstore.KVStore, oldRoot, newRoot common.Hash) ([]AccountDiff, error) {
+	oldTrie, err := trie.NewMPTWithRoot(db, oldRoot)
+	if err != nil {
+		return nil, err
+	}
+	newTrie, err := trie.NewMPTWithRoot(db, newRoot)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := trie.DiffTries(oldTrie, newTrie)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]AccountDiff, 0, len(changes))
+	for _, change := range changes {
+		var before, after *common.Account
+		if change.Old != nil {
+			if before, err = common.BytesToAccount(change.Old); err != nil {
+				return nil, err
+			}
+		}
+		if change.New != nil {
+			if after, err = common.BytesToAccount(change.New); err != nil {
+				return nil, err
+			}
+		}
+
+		var addr common.Address
+		copy(addr[:], change.Key)
+		diff := AccountDiff{
+			Address:        addr,
+			Kind:           AccountModified,
+			BalanceBefore:  diffBalance(before),
+			BalanceAfter:   diffBalance(after),
+			CodeHashBefore: diffCodeHash(before),
+			CodeHashAfter:  diffCodeHash(after),
+		}
+		if diff.Storage, err = diffStorage(db, before, after); err != nil {
+			return nil, err
+		}
+		if before != nil {
+			diff.NonceBefore = before.Nonce
+		} else {
+			diff.Kind = AccountCreated
+		}
+		if after != nil {
+			diff.NonceAfter = after.Nonce
+		} else {
+			diff.Kind = AccountDeleted
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+func diffBalance(acct *common.Account) *big.Int {
+	if acct == nil || acct.Balance == nil {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(acct.Balance)
+}
+
+func diffCodeHash(acct *common.Account) common.Hash {
+	if acct == nil {
+		return common.Hash{}
+	}
+	return acct.CodeHash
+}
+
+// diffStorage 比较两个账户的存储树，账户不存在的一侧视为空树
+func diffStorage(db kvstore.KVStore, before, after *common.Account) ([]StorageDiff, error) {
+	var oldRoot, newRoot common.Hash
+	if before != nil {
+		oldRoot = before.Root
+	}
+	if after != nil {
+		newRoot = after.Root
+	}
+	if oldRoot == newRoot {
+		return nil, nil
+	}
+	oldTrie, err := trie.NewMPTWithRoot(db, oldRoot)
+	if err != nil {
+		return nil, err
+	}
+	newTrie, err := trie.NewMPTWithRoot(db, newRoot)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := trie.DiffTries(oldTrie, newTrie)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]StorageDiff, 0, len(changes))
+	for _, change := range changes {
+		diffs = append(diffs, StorageDiff{
+			Key:    string(change.Key),
+			Before: string(change.Old),
+			After:  string(change.New),
+		})
+	}
+	return diffs, nil
+}
@@ -0,0 +1,115 @@
+package statedb
+
+import (
+	"CHAIN/common"
+	"math/big"
+)
+
+// journalEntry 是一次可撤销的状态修改
+type journalEntry interface {
+	// revert 撤销该修改，调用方需持有 db.lock
+	revert(db *MPTStateDB)
+}
+
+// journal 按顺序记录状态修改，用于回滚到快照
+type journal struct {
+	entries []journalEntry
+}
+
+func (j *journal) append(entry journalEntry) {
+	j.entries = append(j.entries, entry)
+}
+
+func (j *journal) length() int {
+	return len(j.entries)
+}
+
+// revertTo 逆序撤销 index 之后的所有修改
+func (j *journal) revertTo(db *MPTStateDB, index int) {
+	for i := len(j.entries) - 1; i >= index; i-- {
+		j.entries[i].revert(db)
+	}
+	j.entries = j.entries[:index]
+}
+
+type (
+	// 新建账户
+	createAccountChange struct {
+		addr common.Address
+	}
+	// 通过 Store 整体替换账户或通过 DeleteAccount 删除账户；
+	// 缓存中 nil 表示账户已删除，prevExists 区分已删除与未加载
+	resetAccountChange struct {
+		addr       common.Address
+		prev       *common.Account
+		prevExists bool
+	}
+	balanceChange struct {
+		addr common.Address
+		prev *big.Int
+	}
+	nonceChange struct {
+		addr common.Address
+		prev uint64
+	}
+	codeChange struct {
+		addr     common.Address
+		prevCode []byte
+		prevHash common.Hash
+	}
+	storageChange struct {
+		addr       common.Address
+		key        string
+		prev       string
+		prevExists bool
+	}
+)
+
+func (ch createAccountChange) revert(db *MPTStateDB) {
+	delete(db.accounts, ch.addr)
+}
+
+func (ch resetAccountChange) revert(db *MPTStateDB) {
+	if !ch.prevExists {
+		delete(db.accounts, ch.addr)
+		return
+	}
+	db.accounts[ch.addr] = ch.prev
+}
+
+func (ch balanceChange) revert(db *MPTStateDB) {
+	if acct := db.accounts[ch.addr]; acct != nil {
+		acct.Lock()
+		acct.Balance = ch.prev
+		acct.Unlock()
+	}
+}
+
+func (ch nonceChange) revert(db *MPTStateDB) {
+	if acct := db.accounts[ch.addr]; acct != nil {
+		acct.SetNonce(ch.prev)
+	}
+}
+
+func (ch codeChange) revert(db *MPTStateDB) {
+	if acct := db.accounts[ch.addr]; acct != nil {
+		acct.Lock()
+		acct.Code = ch.prevCode
+		acct.CodeHash = ch.prevHash
+		acct.Unlock()
+	}
+}
+
+func (ch storageChange) revert(db *MPTStateDB) {
+	acct := db.accounts[ch.addr]
+	if acct == nil {
+		return
+	}
+	acct.Lock()
+	defer acct.Unlock()
+	if ch.prevExists {
+		acct.Storage[ch.key] = ch.prev
+	} else {
+		delete(acct.Storage, ch.key)
+	}
+}
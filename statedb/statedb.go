@@ -2,121 +2,480 @@ package statedb
 
 import (
 	"CHAIN/common"
+	"CHAIN/kvstore"
+	trie "CHAIN/trie/mpt"
+	"errors"
 	"fmt"
-	"hash"
 	"math/big"
 	"sync"
 )
 
-// InMemoryStateDB 是状态数据库的内存实现
-type InMemoryStateDB struct {
-	root     hash.Hash
-	accounts map[common.Address]*common.Account
-	lock     sync.RWMutex
-}
+// ErrAccountNotFound 表示账户不存在，可用 errors.Is(err, kvstore.ErrNotFound) 判断
+var ErrAccountNotFound = fmt.Errorf("account %w", kvstore.ErrNotFound)
 
-// 构造函数
-func NewInMemoryStateDB() *InMemoryStateDB {
-	return &InMemoryStateDB{
-		accounts: make(map[common.Address]*common.Account),
-	}
-}
+// codePrefix 是合约代码在键值存储中的键前缀，键为 codePrefix || codeHash
+var codePrefix = []byte("code-")
 
 // 接口定义
 type StateDB interface {
-	SetRoot(root hash.Hash)
+	// SetRoot 切换到指定状态根，丢弃所有未提交的修改
+	SetRoot(root common.Hash) error
 	Load(address common.Address) *common.Account
 	Store(address common.Address, account *common.Account)
+
+	GetBalance(addr common.Address) *big.Int
+	AddBalance(addr common.Address, amount *big.Int)
+	SubBalance(addr common.Address, amount *big.Int) error
+	GetNonce(addr common.Address) uint64
+	SetNonce(addr common.Address, nonce uint64)
+	GetCode(addr common.Address) []byte
+	SetCode(addr common.Address, code []byte)
+
+	Snapshot() int
+	RevertToSnapshot(id int)
+	// Commit 把修改写入状态树并返回新的状态根
+	Commit() (common.Hash, error)
+}
+
+// MPTStateDB 是基于 MPT 的状态数据库。
+// 账户在状态树中以 RLP 编码保存，合约代码按哈希单独存储，
+// 每个账户的合约存储保存在以账户 Root 为根的存储树中。
+// 账户在内存中缓存，Commit 时把修改过的账户写入状态树；
+// 所有修改都会记入 journal，可通过 Snapshot / RevertToSnapshot 回滚。
+type MPTStateDB struct {
+	db   kvstore.KVStore
+	trie *trie.MPT
+	root common.Hash
+
+	accounts map[common.Address]*common.Account // 已加载或修改过的账户
+	dirty    map[common.Address]struct{}        // 自上次提交以来修改过的账户
+	dbErr    error                              // 读取状态树时遇到的第一个错误，Commit 时返回
+	lock     sync.RWMutex
+
+	journal        journal
+	validRevisions []revision
+	nextRevisionID int
+}
+
+var _ StateDB = (*MPTStateDB)(nil)
+
+// revision 记录快照 id 与其对应的 journal 位置
+type revision struct {
+	id           int
+	journalIndex int
+}
+
+// New 在 db 上打开状态根为 root 的状态数据库，空哈希表示空状态
+func New(root common.Hash, db kvstore.KVStore) (*MPTStateDB, error) {
+	t, err := trie.NewMPTWithRoot(db, root)
+	if err != nil {
+		return nil, err
+	}
+	return &MPTStateDB{
+		db:       db,
+		trie:     t,
+		root:     root,
+		accounts: make(map[common.Address]*common.Account),
+		dirty:    make(map[common.Address]struct{}),
+	}, nil
+}
+
+// NewInMemoryStateDB 创建一个基于内存键值存储的空状态数据库
+func NewInMemoryStateDB() *MPTStateDB {
+	db, _ := New(common.Hash{}, kvstore.NewMemoryKVStore())
+	return db
 }
 
-// SetRoot 设置根哈希
-func (db *InMemoryStateDB) SetRoot(root hash.Hash) {
+// SetRoot 切换到指定状态根，丢弃缓存与未提交的修改
+func (db *MPTStateDB) SetRoot(root common.Hash) error {
+	t, err := trie.NewMPTWithRoot(db.db, root)
+	if err != nil {
+		return err
+	}
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	db.trie = t
 	db.root = root
+	db.accounts = make(map[common.Address]*common.Account)
+	db.dirty = make(map[common.Address]struct{})
+	db.dbErr = nil
+	db.resetJournal()
+	return nil
 }
 
-// Load 读取账户
-func (db *InMemoryStateDB) Load(address common.Address) *common.Account {
+// Root 返回最近一次提交（或打开）时的状态根
+func (db *MPTStateDB) Root() common.Hash {
 	db.lock.RLock()
 	defer db.lock.RUnlock()
-	return db.accounts[address]
+	return db.root
+}
+
+// Commit 把修改过的账户写入状态树，返回新的状态根。
+// 提交后 journal 清空，之前的快照全部失效。
+func (db *MPTStateDB) Commit() (common.Hash, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.dbErr != nil {
+		return common.Hash{}, db.dbErr
+	}
+	for addr := range db.dirty {
+		acct, ok := db.accounts[addr]
+		if !ok {
+			// 修改已被回滚，状态树中的数据保持不变
+			continue
+		}
+		if acct == nil {
+			// 账户已被删除
+			if err := db.trie.Delete(addr[:]); err != nil {
+				return common.Hash{}, err
+			}
+			continue
+		}
+		if err := db.commitCode(acct); err != nil {
+			return common.Hash{}, err
+		}
+		if err := db.commitStorage(acct); err != nil {
+			return common.Hash{}, err
+		}
+		data, err := acct.Bytes()
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if err := db.trie.Insert(addr[:], data); err != nil {
+			return common.Hash{}, err
+		}
+	}
+
+	root, err := db.trie.Commit()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	db.root = root
+	db.dirty = make(map[common.Address]struct{})
+	db.resetJournal()
+	return root, nil
+}
+
+// commitCode 以代码哈希为键保存合约代码，调用方需持有 db.lock
+func (db *MPTStateDB) commitCode(acct *common.Account) error {
+	acct.RLock()
+	defer acct.RUnlock()
+	if len(acct.Code) == 0 {
+		return nil
+	}
+	return db.db.Put(codeKey(acct.CodeHash), acct.Code)
+}
+
+// commitStorage 把缓存中的存储槽写入账户的存储树并更新存储根，值为空字符串的槽从树中删除，
+// 调用方需持有 db.lock。未修改的槽写入后节点不变，因此不必单独记录哪些槽被修改过
+func (db *MPTStateDB) commitStorage(acct *common.Account) error {
+	acct.Lock()
+	defer acct.Unlock()
+	if len(acct.Storage) == 0 {
+		return nil
+	}
+	t, err := trie.NewMPTWithRoot(db.db, acct.Root)
+	if err != nil {
+		return err
+	}
+	for key, value := range acct.Storage {
+		if value == "" {
+			err = t.Delete([]byte(key))
+		} else {
+			err = t.Insert([]byte(key), []byte(value))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	root, err := t.Commit()
+	if err != nil {
+		return err
+	}
+	acct.Root = root
+	return nil
+}
+
+// Load 读取账户
+func (db *MPTStateDB) Load(address common.Address) *common.Account {
+	return db.GetAccount(address)
 }
 
 // Store 存储账户
-func (db *InMemoryStateDB) Store(address common.Address, account *common.Account) {
+func (db *MPTStateDB) Store(address common.Address, account *common.Account) {
 	db.lock.Lock()
 	defer db.lock.Unlock()
+	prev, prevExists := db.accounts[address]
+	db.journal.append(resetAccountChange{addr: address, prev: prev, prevExists: prevExists})
 	db.accounts[address] = account
+	db.dirty[address] = struct{}{}
 }
 
-// 获取账户（内部方法）
-func (db *InMemoryStateDB) GetAccount(addr common.Address) *common.Account {
-	db.lock.RLock()
-	defer db.lock.RUnlock()
-	return db.accounts[addr]
+// DeleteAccount 删除账户及其存储，提交后账户从状态树中移除
+func (db *MPTStateDB) DeleteAccount(addr common.Address) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	prev, prevExists := db.accounts[addr]
+	db.journal.append(resetAccountChange{addr: addr, prev: prev, prevExists: prevExists})
+	db.accounts[addr] = nil
+	db.dirty[addr] = struct{}{}
+}
+
+// 获取账户，缓存中没有时从状态树加载
+func (db *MPTStateDB) GetAccount(addr common.Address) *common.Account {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	return db.getAccount(addr)
+}
+
+// getAccount 调用方需持有 db.lock
+func (db *MPTStateDB) getAccount(addr common.Address) *common.Account {
+	if acct, ok := db.accounts[addr]; ok {
+		return acct
+	}
+
+	data, err := db.trie.Search(addr[:])
+	if err != nil {
+		if !errors.Is(err, kvstore.ErrNotFound) && db.dbErr == nil {
+			db.dbErr = err
+		}
+		return nil
+	}
+	acct, err := common.BytesToAccount(data)
+	if err != nil {
+		if db.dbErr == nil {
+			db.dbErr = err
+		}
+		return nil
+	}
+	acct.Address = addr
+	db.accounts[addr] = acct
+	return acct
 }
 
 // 创建账户
-func (db *InMemoryStateDB) CreateAccount(addr common.Address) *common.Account {
+func (db *MPTStateDB) CreateAccount(addr common.Address) *common.Account {
 	db.lock.Lock()
 	defer db.lock.Unlock()
+	return db.getOrCreateAccount(addr)
+}
 
-	acct, exists := db.accounts[addr]
-	if exists {
+// getOrCreateAccount 返回账户，不存在时新建并记入 journal，调用方需持有 db.lock
+func (db *MPTStateDB) getOrCreateAccount(addr common.Address) *common.Account {
+	if acct := db.getAccount(addr); acct != nil {
+		db.dirty[addr] = struct{}{}
 		return acct
 	}
 
+	if _, deleted := db.accounts[addr]; deleted {
+		// 回滚时恢复为已删除，而不是重新从状态树加载
+		db.journal.append(resetAccountChange{addr: addr, prevExists: true})
+	} else {
+		db.journal.append(createAccountChange{addr: addr})
+	}
 	newAcct := common.NewAccount(addr)
 	db.accounts[addr] = newAcct
+	db.dirty[addr] = struct{}{}
 	return newAcct
 }
 
 // 增加余额
-func (db *InMemoryStateDB) AddBalance(addr common.Address, amount *big.Int) {
-	acct := db.GetAccount(addr)
-	if acct == nil {
-		acct = db.CreateAccount(addr)
-	}
+func (db *MPTStateDB) AddBalance(addr common.Address, amount *big.Int) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	acct := db.getOrCreateAccount(addr)
+	db.journal.append(balanceChange{addr: addr, prev: balanceOf(acct)})
 	acct.AddBalance(amount)
 }
 
 // 扣减余额
-func (db *InMemoryStateDB) SubBalance(addr common.Address, amount *big.Int) error {
-	acct := db.GetAccount(addr)
+func (db *MPTStateDB) SubBalance(addr common.Address, amount *big.Int) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	acct := db.getAccount(addr)
 	if acct == nil {
-		return fmt.Errorf("account not found: %s", addr.String())
+		return fmt.Errorf("%w: %s", ErrAccountNotFound, addr.String())
 	}
-	if acct.Balance.Cmp(amount) < 0 {
+	prev := balanceOf(acct)
+	if prev.Cmp(amount) < 0 {
 		return fmt.Errorf("insufficient balance")
 	}
+	db.dirty[addr] = struct{}{}
+	db.journal.append(balanceChange{addr: addr, prev: prev})
 	acct.SubBalance(amount)
 	return nil
 }
 
 // 查询余额
-func (db *InMemoryStateDB) GetBalance(addr common.Address) *big.Int {
+func (db *MPTStateDB) GetBalance(addr common.Address) *big.Int {
 	acct := db.GetAccount(addr)
 	if acct == nil {
 		return big.NewInt(0)
 	}
-	acct.Lock()
-	defer acct.Unlock()
-	return new(big.Int).Set(acct.Balance)
+	return balanceOf(acct)
 }
 
 // 设置 Nonce
-func (db *InMemoryStateDB) SetNonce(addr common.Address, nonce uint64) {
-	acct := db.GetAccount(addr)
-	if acct == nil {
-		acct = db.CreateAccount(addr)
-	}
+func (db *MPTStateDB) SetNonce(addr common.Address, nonce uint64) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	acct := db.getOrCreateAccount(addr)
+	db.journal.append(nonceChange{addr: addr, prev: acct.GetNonce()})
 	acct.SetNonce(nonce)
 }
 
 // 获取 Nonce
-func (db *InMemoryStateDB) GetNonce(addr common.Address) uint64 {
+func (db *MPTStateDB) GetNonce(addr common.Address) uint64 {
 	acct := db.GetAccount(addr)
 	if acct == nil {
 		return 0
 	}
 	return acct.GetNonce()
 }
+
+// SetCode 设置合约代码
+func (db *MPTStateDB) SetCode(addr common.Address, code []byte) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	acct := db.getOrCreateAccount(addr)
+	prev := db.getCode(acct)
+	acct.RLock()
+	prevHash := acct.CodeHash
+	acct.RUnlock()
+	db.journal.append(codeChange{addr: addr, prevCode: prev, prevHash: prevHash})
+	acct.SetCode(code)
+}
+
+// GetCode 获取合约代码
+func (db *MPTStateDB) GetCode(addr common.Address) []byte {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	acct := db.getAccount(addr)
+	if acct == nil {
+		return nil
+	}
+	return db.getCode(acct)
+}
+
+// getCode 返回账户代码，未加载时按代码哈希从数据库读取，调用方需持有 db.lock
+func (db *MPTStateDB) getCode(acct *common.Account) []byte {
+	acct.Lock()
+	defer acct.Unlock()
+	if acct.Code != nil || acct.CodeHash.IsEmpty() || acct.CodeHash == common.EmptyCodeHash {
+		return acct.Code
+	}
+	code, err := db.db.Get(codeKey(acct.CodeHash))
+	if err != nil {
+		if db.dbErr == nil {
+			db.dbErr = fmt.Errorf("code %s of account %s: %w", acct.CodeHash, acct.Address, err)
+		}
+		return nil
+	}
+	acct.Code = code
+	return code
+}
+
+// SetState 设置合约存储
+func (db *MPTStateDB) SetState(addr common.Address, key, value string) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	acct := db.getOrCreateAccount(addr)
+	prev, exists := db.getState(acct, key)
+	db.journal.append(storageChange{addr: addr, key: key, prev: prev, prevExists: exists})
+	acct.Lock()
+	defer acct.Unlock()
+	if acct.Storage == nil {
+		acct.Storage = make(map[string]string)
+	}
+	acct.Storage[key] = value
+}
+
+// GetState 读取合约存储
+func (db *MPTStateDB) GetState(addr common.Address, key string) string {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	acct := db.getAccount(addr)
+	if acct == nil {
+		return ""
+	}
+	value, _ := db.getState(acct, key)
+	return value
+}
+
+// getState 返回存储槽的值及其是否存在，缓存中没有时从存储树加载，调用方需持有 db.lock
+func (db *MPTStateDB) getState(acct *common.Account, key string) (string, bool) {
+	acct.Lock()
+	defer acct.Unlock()
+	if value, ok := acct.Storage[key]; ok {
+		return value, true
+	}
+	if acct.Root.IsEmpty() {
+		return "", false
+	}
+	t, err := trie.NewMPTWithRoot(db.db, acct.Root)
+	if err == nil {
+		var value []byte
+		if value, err = t.Search([]byte(key)); err == nil {
+			if acct.Storage == nil {
+				acct.Storage = make(map[string]string)
+			}
+			acct.Storage[key] = string(value)
+			return string(value), true
+		}
+	}
+	if !errors.Is(err, kvstore.ErrNotFound) && db.dbErr == nil {
+		db.dbErr = err
+	}
+	return "", false
+}
+
+// Snapshot 创建一个快照并返回其 id，可用于 RevertToSnapshot
+func (db *MPTStateDB) Snapshot() int {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	id := db.nextRevisionID
+	db.nextRevisionID++
+	db.validRevisions = append(db.validRevisions, revision{id: id, journalIndex: db.journal.length()})
+	return id
+}
+
+// RevertToSnapshot 撤销快照 id 之后的所有修改，之后创建的快照一并失效
+func (db *MPTStateDB) RevertToSnapshot(id int) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	idx := -1
+	for i, rev := range db.validRevisions {
+		if rev.id == id {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		panic(fmt.Sprintf("revision id %d cannot be reverted", id))
+	}
+	db.journal.revertTo(db, db.validRevisions[idx].journalIndex)
+	db.validRevisions = db.validRevisions[:idx]
+}
+
+// resetJournal 清空 journal 与快照，调用方需持有 db.lock
+func (db *MPTStateDB) resetJournal() {
+	db.journal = journal{}
+	db.validRevisions = nil
+}
+
+// balanceOf 返回账户余额的拷贝，账户余额为 nil 时视为 0
+func balanceOf(acct *common.Account) *big.Int {
+	acct.RLock()
+	defer acct.RUnlock()
+	if acct.Balance == nil {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(acct.Balance)
+}
+
+func codeKey(hash common.Hash) []byte {
+	return append(append([]byte{}, codePrefix...), hash[:]...)
+}
@@ -0,0 +1,374 @@
+package statedb
+
+import (
+	"CHAIN/common"
+	"CHAIN/kvstore"
+	trie "CHAIN/trie/mpt"
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestSnapshotRevert(t *testing.T) {
+	db := NewInMemoryStateDB()
+	addrA := common.Address{1}
+	addrB := common.Address{2}
+
+	db.AddBalance(addrA, big.NewInt(1000))
+	db.SetNonce(addrA, 1)
+	db.SetState(addrA, "k", "v1")
+
+	snap := db.Snapshot()
+	db.AddBalance(addrA, big.NewInt(500))
+	if err := db.SubBalance(addrA, big.NewInt(200)); err != nil {
+		t.Fatalf("SubBalance failed: %v", err)
+	}
+	db.SetNonce(addrA, 2)
+	db.SetCode(addrA, []byte{0x60, 0x00})
+	db.SetState(addrA, "k", "v2")
+	db.SetState(addrA, "new", "x")
+	db.AddBalance(addrB, big.NewInt(200))
+
+	db.RevertToSnapshot(snap)
+
+	if got := db.GetBalance(addrA); got.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("balance after revert = %s, want 1000", got)
+	}
+	if got := db.GetNonce(addrA); got != 1 {
+		t.Errorf("nonce after revert = %d, want 1", got)
+	}
+	if code := db.GetCode(addrA); code != nil {
+		t.Errorf("code after revert = %x, want nil", code)
+	}
+	if v := db.GetState(addrA, "k"); v != "v1" {
+		t.Errorf("storage k after revert = %q, want v1", v)
+	}
+	if _, ok := db.GetAccount(addrA).Storage["new"]; ok {
+		t.Error("storage key created after snapshot should be removed")
+	}
+	if db.GetAccount(addrB) != nil {
+		t.Error("account created after snapshot should be removed")
+	}
+}
+
+func TestNestedSnapshots(t *testing.T) {
+	db := NewInMemoryStateDB()
+	addr := common.Address{1}
+
+	db.AddBalance(addr, big.NewInt(10))
+	outer := db.Snapshot()
+	db.AddBalance(addr, big.NewInt(10))
+	inner := db.Snapshot()
+	db.AddBalance(addr, big.NewInt(10))
+
+	db.RevertToSnapshot(inner)
+	if got := db.GetBalance(addr); got.Int64() != 20 {
+		t.Fatalf("balance after inner revert = %s, want 20", got)
+	}
+	db.RevertToSnapshot(outer)
+	if got := db.GetBalance(addr); got.Int64() != 10 {
+		t.Fatalf("balance after outer revert = %s, want 10", got)
+	}
+
+	// 回滚到外层快照后，内层快照失效
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic reverting to invalidated snapshot")
+		}
+	}()
+	db.RevertToSnapshot(inner)
+}
+
+func TestSubBalanceErrors(t *testing.T) {
+	db := NewInMemoryStateDB()
+	addr := common.Address{1}
+
+	err := db.SubBalance(addr, big.NewInt(1))
+	if !errors.Is(err, ErrAccountNotFound) || !errors.Is(err, kvstore.ErrNotFound) {
+		t.Fatalf("SubBalance of unknown account = %v, want ErrAccountNotFound", err)
+	}
+
+	db.AddBalance(addr, big.NewInt(5))
+	if err := db.SubBalance(addr, big.NewInt(6)); err == nil {
+		t.Fatal("expected insufficient balance error")
+	}
+	if got := db.GetBalance(addr); got.Int64() != 5 {
+		t.Fatalf("failed SubBalance changed balance to %s", got)
+	}
+}
+
+func TestZeroValueAccountBalance(t *testing.T) {
+	db := NewInMemoryStateDB()
+	addr := common.Address{1}
+
+	// 零值账户的 Balance 为 nil，按 0 处理
+	db.Store(addr, &common.Account{})
+	db.AddBalance(addr, big.NewInt(3))
+	if err := db.SubBalance(addr, big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	if got := db.GetBalance(addr); got.Int64() != 2 {
+		t.Fatalf("balance = %s, want 2", got)
+	}
+}
+
+func TestCommitAndReopen(t *testing.T) {
+	kv := kvstore.NewMemoryKVStore()
+	db, err := New(common.Hash{}, kv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addrA := common.Address{1}
+	addrB := common.Address{2}
+
+	db.AddBalance(addrA, big.NewInt(1000))
+	db.SetNonce(addrA, 3)
+	db.SetCode(addrB, []byte{0x60, 0x01})
+	db.SetState(addrB, "slot", "value")
+
+	root, err := db.Commit()
+	if err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if root.IsEmpty() {
+		t.Fatal("Commit returned empty root")
+	}
+
+	// 相同内容的状态得到相同的根
+	other := NewInMemoryStateDB()
+	other.SetCode(addrB, []byte{0x60, 0x01})
+	other.SetState(addrB, "slot", "value")
+	other.SetNonce(addrA, 3)
+	other.AddBalance(addrA, big.NewInt(1000))
+	otherRoot, err := other.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherRoot != root {
+		t.Fatalf("same state produced different roots: %x != %x", root, otherRoot)
+	}
+
+	reopened, err := New(root, kv)
+	if err != nil {
+		t.Fatalf("New at committed root failed: %v", err)
+	}
+	if got := reopened.GetBalance(addrA); got.Int64() != 1000 {
+		t.Errorf("reopened balance = %s, want 1000", got)
+	}
+	if got := reopened.GetNonce(addrA); got != 3 {
+		t.Errorf("reopened nonce = %d, want 3", got)
+	}
+	if got := reopened.GetState(addrB, "slot"); got != "value" {
+		t.Errorf("reopened storage = %q, want value", got)
+	}
+
+	// 修改后再提交，旧根保持可读
+	reopened.AddBalance(addrA, big.NewInt(1))
+	newRoot, err := reopened.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newRoot == root {
+		t.Fatal("root unchanged after modification")
+	}
+	if err := reopened.SetRoot(root); err != nil {
+		t.Fatalf("SetRoot failed: %v", err)
+	}
+	if got := reopened.GetBalance(addrA); got.Int64() != 1000 {
+		t.Errorf("balance at old root = %s, want 1000", got)
+	}
+}
+
+func TestRevertBeforeCommit(t *testing.T) {
+	kv := kvstore.NewMemoryKVStore()
+	db, _ := New(common.Hash{}, kv)
+	addr := common.Address{1}
+	db.AddBalance(addr, big.NewInt(10))
+	root, _ := db.Commit()
+
+	snap := db.Snapshot()
+	db.AddBalance(addr, big.NewInt(5))
+	db.AddBalance(common.Address{2}, big.NewInt(5))
+	db.RevertToSnapshot(snap)
+
+	after, err := db.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after != root {
+		t.Fatalf("reverted changes leaked into commit: %x != %x", after, root)
+	}
+}
+
+func TestStateDiff(t *testing.T) {
+	kv := kvstore.NewMemoryKVStore()
+	db, _ := New(common.Hash{}, kv)
+	addrA := common.Address{1}
+	addrB := common.Address{2}
+	addrC := common.Address{3}
+
+	db.AddBalance(addrA, big.NewInt(100))
+	db.AddBalance(addrB, big.NewInt(50))
+	db.SetState(addrB, "keep", "1")
+	db.SetState(addrB, "change", "old")
+	oldRoot, _ := db.Commit()
+
+	db.SubBalance(addrA, big.NewInt(30))
+	db.SetNonce(addrA, 1)
+	db.SetState(addrB, "change", "new")
+	db.SetState(addrB, "added", "x")
+	db.SetCode(addrC, []byte{0x60})
+	newRoot, _ := db.Commit()
+
+	diffs, err := StateDiff(kv, oldRoot, newRoot)
+	if err != nil {
+		t.Fatalf("StateDiff failed: %v", err)
+	}
+	if len(diffs) != 3 {
+		t.Fatalf("got %d diffs, want 3: %+v", len(diffs), diffs)
+	}
+
+	a := diffs[0]
+	if a.Address != addrA || a.Kind != AccountModified ||
+		a.BalanceBefore.Int64() != 100 || a.BalanceAfter.Int64() != 70 ||
+		a.NonceBefore != 0 || a.NonceAfter != 1 || len(a.Storage) != 0 {
+		t.Errorf("unexpected diff for A: %+v", a)
+	}
+
+	b := diffs[1]
+	wantStorage := []StorageDiff{{Key: "added", After: "x"}, {Key: "change", Before: "old", After: "new"}}
+	if b.Address != addrB || b.Kind != AccountModified || len(b.Storage) != 2 ||
+		b.Storage[0] != wantStorage[0] || b.Storage[1] != wantStorage[1] {
+		t.Errorf("unexpected diff for B: %+v", b)
+	}
+
+	c := diffs[2]
+	if c.Address != addrC || c.Kind != AccountCreated || c.CodeHashAfter == c.CodeHashBefore {
+		t.Errorf("unexpected diff for C: %+v", c)
+	}
+
+	reverse, _ := StateDiff(kv, newRoot, oldRoot)
+	if reverse[2].Kind != AccountDeleted {
+		t.Errorf("reverse diff kind for C = %s, want deleted", reverse[2].Kind)
+	}
+}
+
+func TestDeleteAccountAndStorage(t *testing.T) {
+	kv := kvstore.NewMemoryKVStore()
+	db, _ := New(common.Hash{}, kv)
+	addrA := common.Address{1}
+	addrB := common.Address{2}
+
+	db.AddBalance(addrA, big.NewInt(100))
+	db.SetState(addrA, "keep", "1")
+	baseRoot, _ := db.Commit()
+
+	db.SetState(addrA, "temp", "2")
+	db.AddBalance(addrB, big.NewInt(50))
+	fullRoot, _ := db.Commit()
+
+	// 清空存储槽并删除账户后，状态根与从未写入时相同
+	db.SetState(addrA, "temp", "")
+	db.DeleteAccount(addrB)
+	if db.Load(addrB) != nil || db.GetBalance(addrB).Sign() != 0 {
+		t.Fatal("deleted account still readable")
+	}
+	root, err := db.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != baseRoot {
+		t.Fatalf("root after deletion = %x, want %x", root, baseRoot)
+	}
+
+	diffs, err := StateDiff(kv, fullRoot, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 2 || diffs[0].Storage[0] != (StorageDiff{Key: "temp", Before: "2"}) || diffs[1].Kind != AccountDeleted {
+		t.Fatalf("unexpected diffs: %+v", diffs)
+	}
+
+	// 删除可以回滚；回滚删除后重建的账户仍恢复为已删除
+	db.AddBalance(addrA, big.NewInt(1))
+	snap := db.Snapshot()
+	db.DeleteAccount(addrA)
+	inner := db.Snapshot()
+	db.AddBalance(addrA, big.NewInt(7))
+	db.RevertToSnapshot(inner)
+	if db.Load(addrA) != nil {
+		t.Fatal("recreated account survived revert")
+	}
+	db.RevertToSnapshot(snap)
+	if got := db.GetBalance(addrA); got.Int64() != 101 {
+		t.Fatalf("balance after reverting deletion = %s, want 101", got)
+	}
+}
+
+func TestAccountEncoding(t *testing.T) {
+	kv := kvstore.NewMemoryKVStore()
+	db, _ := New(common.Hash{}, kv)
+	addr := common.Address{1}
+	code := []byte{0x60, 0x01, 0x60, 0x02}
+
+	db.AddBalance(addr, big.NewInt(7))
+	db.SetNonce(addr, 2)
+	db.SetCode(addr, code)
+	db.SetState(addr, "slot", "value")
+	root, err := db.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 状态树中的叶子只包含 [nonce, balance, storageRoot, codeHash]
+	accTrie, _ := trie.NewMPTWithRoot(kv, root)
+	data, err := accTrie.Search(addr[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var leaf struct {
+		Nonce    uint64
+		Balance  *big.Int
+		Root     common.Hash
+		CodeHash common.Hash
+	}
+	if err := rlp.DecodeBytes(data, &leaf); err != nil {
+		t.Fatalf("account leaf is not RLP: %v", err)
+	}
+	wantCodeHash := common.BytesToHash(crypto.Keccak256(code))
+	if leaf.Nonce != 2 || leaf.Balance.Int64() != 7 || leaf.CodeHash != wantCodeHash || leaf.Root.IsEmpty() {
+		t.Fatalf("unexpected account leaf: %+v", leaf)
+	}
+	if stored, err := kv.Get(codeKey(wantCodeHash)); err != nil || !bytes.Equal(stored, code) {
+		t.Fatalf("code stored by hash = %x, %v", stored, err)
+	}
+
+	// 没有代码的账户使用空代码哈希
+	eoa := common.Address{2}
+	db.AddBalance(eoa, big.NewInt(1))
+	root, _ = db.Commit()
+	reopened, _ := New(root, kv)
+	if got := reopened.GetAccount(eoa).CodeHash; got != common.EmptyCodeHash {
+		t.Errorf("EOA code hash = %s, want %s", got, common.EmptyCodeHash)
+	}
+	if got := reopened.GetCode(addr); !bytes.Equal(got, code) {
+		t.Errorf("reopened code = %x, want %x", got, code)
+	}
+	if got := reopened.GetState(addr, "slot"); got != "value" {
+		t.Errorf("reopened storage = %q, want value", got)
+	}
+
+	// 只修改存储时存储根随之变化，代码不必重新写入
+	storageRoot := reopened.GetAccount(addr).Root
+	reopened.SetState(addr, "slot", "other")
+	if _, err := reopened.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if reopened.GetAccount(addr).Root == storageRoot {
+		t.Error("storage root unchanged after SetState")
+	}
+}
@@ -0,0 +1,179 @@
+package trie
+
+import (
+	"CHAIN/common"
+	"bytes"
+	"sort"
+
+	common2 "github.com/ethereum/go-ethereum/common"
+)
+
+// KeyChange 描述两棵树之间某个键的变化，Old / New 为 nil 表示该侧不存在此键
+type KeyChange struct {
+	Key []byte
+	Old []byte
+	New []byte
+}
+
+// DiffTries 并行遍历两棵树，跳过哈希相同的子树，按键升序返回所有值不同的键
+func DiffTries(oldTrie, newTrie *MPT) ([]KeyChange, error) {
+	d := &trieDiffer{oldTrie: oldTrie, newTrie: newTrie}
+	if err := d.diff(nil, oldTrie.Root, newTrie.Root); err != nil {
+		return nil, err
+	}
+	sort.Slice(d.changes, func(i, j int) bool {
+		return bytes.Compare(d.changes[i].Key, d.changes[j].Key) < 0
+	})
+	return d.changes, nil
+}
+
+type trieDiffer struct {
+	oldTrie *MPT
+	newTrie *MPT
+	changes []KeyChange
+}
+
+// diff 比较同一路径前缀下的两个子树
+func (d *trieDiffer) diff(prefix []Nibble, oldNode, newNode Node) error {
+	if oldNode != nil && newNode != nil && oldNode.GetHash() == newNode.GetHash() {
+		return nil
+	}
+
+	// 两边都是分支节点时逐个子槽比较，哈希相同的子树直接跳过
+	oldBranch, oldIsBranch := oldNode.(*BranchNode)
+	newBranch, newIsBranch := newNode.(*BranchNode)
+	if oldIsBranch && newIsBranch {
+		if oldBranch.Value != newBranch.Value {
+			if err := d.record(prefix, oldBranch.Value, newBranch.Value); err != nil {
+				return err
+			}
+		}
+		for i := 0; i < 16; i++ {
+			oldChild, newChild := oldBranch.Children[i], newBranch.Children[i]
+			if oldChild == newChild {
+				continue
+			}
+			o, err := d.load(d.oldTrie, oldChild)
+			if err != nil {
+				return err
+			}
+			n, err := d.load(d.newTrie, newChild)
+			if err != nil {
+				return err
+			}
+			if err := d.diff(appendNibble(prefix, Nibble(i)), o, n); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// 路径相同的扩展节点继续向下比较
+	oldExt, oldIsExt := oldNode.(*ExtensionNode)
+	newExt, newIsExt := newNode.(*ExtensionNode)
+	if oldIsExt && newIsExt && nibblesEqual(oldExt.Path, newExt.Path) {
+		o, err := d.load(d.oldTrie, oldExt.Child)
+		if err != nil {
+			return err
+		}
+		n, err := d.load(d.newTrie, newExt.Child)
+		if err != nil {
+			return err
+		}
+		return d.diff(append(append([]Nibble{}, prefix...), oldExt.Path...), o, n)
+	}
+
+	// 结构不同：展开两侧子树的全部叶子后比较
+	oldLeaves := make(map[string]common2.Hash)
+	newLeaves := make(map[string]common2.Hash)
+	if err := d.collect(d.oldTrie, prefix, oldNode, oldLeaves); err != nil {
+		return err
+	}
+	if err := d.collect(d.newTrie, prefix, newNode, newLeaves); err != nil {
+		return err
+	}
+	for key, oldValue := range oldLeaves {
+		if newValue := newLeaves[key]; newValue != oldValue {
+			if err := d.recordKey([]byte(key), oldValue, newValue); err != nil {
+				return err
+			}
+		}
+	}
+	for key, newValue := range newLeaves {
+		if _, ok := oldLeaves[key]; !ok {
+			if err := d.recordKey([]byte(key), common2.Hash{}, newValue); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// collect 收集子树中所有键及其值哈希
+func (d *trieDiffer) collect(t *MPT, prefix []Nibble, node Node, out map[string]common2.Hash) error {
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case *LeafNode:
+		path := append(append([]Nibble{}, prefix...), n.Path...)
+		out[string(nibbleToBytes(path))] = n.Value
+		return nil
+	case *ExtensionNode:
+		child, err := d.load(t, n.Child)
+		if err != nil {
+			return err
+		}
+		return d.collect(t, append(append([]Nibble{}, prefix...), n.Path...), child, out)
+	case *BranchNode:
+		if n.Value != (common2.Hash{}) {
+			out[string(nibbleToBytes(prefix))] = n.Value
+		}
+		for i, childHash := range n.Children {
+			if childHash == (common2.Hash{}) {
+				continue
+			}
+			child, err := d.load(t, childHash)
+			if err != nil {
+				return err
+			}
+			if err := d.collect(t, appendNibble(prefix, Nibble(i)), child, out); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// load 加载子节点，空哈希表示不存在
+func (d *trieDiffer) load(t *MPT, hash common2.Hash) (Node, error) {
+	if hash == (common2.Hash{}) {
+		return nil, nil
+	}
+	return t.getNodeByHash(common.Hash(hash))
+}
+
+func (d *trieDiffer) record(path []Nibble, oldValue, newValue common2.Hash) error {
+	return d.recordKey(nibbleToBytes(path), oldValue, newValue)
+}
+
+// recordKey 读取两侧的值并记录变化，空哈希表示该侧不存在
+func (d *trieDiffer) recordKey(key []byte, oldValue, newValue common2.Hash) error {
+	change := KeyChange{Key: key}
+	var err error
+	if oldValue != (common2.Hash{}) {
+		if change.Old, err = d.oldTrie.getValue(oldValue[:]); err != nil {
+			return err
+		}
+	}
+	if newValue != (common2.Hash{}) {
+		if change.New, err = d.newTrie.getValue(newValue[:]); err != nil {
+			return err
+		}
+	}
+	d.changes = append(d.changes, change)
+	return nil
+}
+
+func appendNibble(prefix []Nibble, n Nibble) []Nibble {
+	return append(append(make([]Nibble, 0, len(prefix)+1), prefix...), n)
+}
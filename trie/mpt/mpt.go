@@ -3,19 +3,21 @@ package trie
 import (
 	"CHAIN/common"
 	"CHAIN/kvstore"
-	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	common2 "github.com/ethereum/go-ethereum/common"
-	"github.com/syndtr/goleveldb/leveldb/errors"
 )
 
 // MPT 是Merkle Patricia Trie的主结构
 // path类型都改成nibble
 
-// MPT_KEY_NOT_FOUND 是键不存在时的错误
-var MPT_KEY_NOT_FOUND = errors.New("MPT: Key not found")
-var extNode *ExtensionNode
+// MPT_KEY_NOT_FOUND 是键不存在时的错误，可用 errors.Is(err, kvstore.ErrNotFound) 判断
+var MPT_KEY_NOT_FOUND = fmt.Errorf("MPT: key %w", kvstore.ErrNotFound)
+
+// ErrMissingNode 表示树中引用的节点或值在底层存储中缺失（数据损坏），
+// 与键不存在区分开
+var ErrMissingNode = errors.New("MPT: missing trie node")
 
 type MPT struct {
 	Root Node
@@ -31,7 +33,7 @@ func (m *MPT) RootHash() (common.Hash, error) {
 }
 
 func (m *MPT) Commit() (common.Hash, error) {
-	// 提交所有更改到数据库
+	// 节点在插入时已经写入数据库，这里只返回根哈希
 	if m.Root == nil {
 		return common.Hash{}, nil
 	}
@@ -45,6 +47,20 @@ func NewMPT(db kvstore.KVStore) *MPT {
 	}
 }
 
+// NewMPTWithRoot 打开数据库中根哈希为 root 的树，空哈希表示空树
+func NewMPTWithRoot(db kvstore.KVStore, root common.Hash) (*MPT, error) {
+	m := NewMPT(db)
+	if root.IsEmpty() {
+		return m, nil
+	}
+	node, err := m.getNodeByHash(root)
+	if err != nil {
+		return nil, err
+	}
+	m.Root = node
+	return m, nil
+}
+
 // FindLongestPrefix 查找与给定key有最长公共前缀的节点路径
 func (m *MPT) FindLongestPrefix(key []Nibble) (paths [][]Nibble, nodes []Node) {
 	var currentNode Node = m.Root
@@ -69,7 +85,11 @@ func (m *MPT) FindLongestPrefix(key []Nibble) (paths [][]Nibble, nodes []Node) {
 			}
 			paths = append(paths, ext.Path[:plength])
 			nodes = append(nodes, ext)
-			currentNode = m.getNodeByHash(common.Hash(ext.Child))
+			child, err := m.getNodeByHash(common.Hash(ext.Child))
+			if err != nil {
+				return
+			}
+			currentNode = child
 
 		case BranchNodeType:
 			return
@@ -78,308 +98,321 @@ func (m *MPT) FindLongestPrefix(key []Nibble) (paths [][]Nibble, nodes []Node) {
 	return
 }
 
-// Insert 非递归实现
+// Insert 插入或更新 key 对应的值。
+// 值本身以其哈希为键单独存储，叶子节点只保存值哈希；
+// 沿途被修改的节点都会生成新节点并写入数据库，旧节点保持不变。
 func (m *MPT) Insert(key, value []byte) error {
-	nibbles := convertToNibbles(key)
 	valueHash := Sha3_256(value)
-
-	// 新增：存储原始 value，key 为 valueHash
 	if err := m.db.Put(valueHash.Bytes(), value); err != nil {
 		return err
 	}
 
-	if m.Root == nil {
-		leaf := NewLeafNode(nibbles, common.Hash(valueHash))
-		m.Root = leaf
-		m.db.Put(leaf.GetHash().Bytes(), leaf.Serialize())
-		return nil
-	}
-
-	// 使用栈来管理待处理节点和路径
-	type stackItem struct {
-		node        Node
-		parent      Node         // 父节点
-		parentField *common.Hash // 父节点中指向当前节点的字段
-		nibbles     []Nibble     // 剩余待处理的nibbles
+	root, err := m.insert(m.Root, convertToNibbles(key), valueHash)
+	if err != nil {
+		return err
 	}
+	m.Root = root
+	return nil
+}
 
-	var rootModified bool
-	var newRoot Node
-	stack := []stackItem{{
-		node:    m.Root,
-		nibbles: nibbles,
-	}}
-
-	for len(stack) > 0 {
-		item := stack[len(stack)-1]
-		stack = stack[:len(stack)-1]
-
-		currentNode := item.node
-		remainingNibbles := item.nibbles
-
-		switch node := currentNode.(type) {
-		case *LeafNode:
-			// 查找公共前缀
-			commonPrefix := findCommonPrefix(node.Path, remainingNibbles)
-
-			// 完全匹配则更新值
-			if len(commonPrefix) == len(node.Path) && len(commonPrefix) == len(remainingNibbles) {
-				node.Value = valueHash
-				m.db.Put(node.GetHash().Bytes(), node.Serialize())
-				if item.parent != nil {
-					*item.parentField = m.storeNode(node)
-				} else {
-					rootModified = true
-					newRoot = node
-				}
-				continue
-			}
-
-			// 创建分支节点处理部分匹配
-			branch := &BranchNode{}
-
-			// 处理现有叶子节点
-			if len(node.Path) > len(commonPrefix) {
-				nibble := node.Path[len(commonPrefix)]
-				remainingPath := node.Path[len(commonPrefix)+1:]
+// insert 把值插入以 node 为根的子树，返回新的子树根
+func (m *MPT) insert(node Node, path []Nibble, value common2.Hash) (Node, error) {
+	switch n := node.(type) {
+	case nil:
+		return m.persist(NewLeafNode(path, common.Hash(value)))
+
+	case *LeafNode:
+		plength := prefixLength(n.Path, path)
+		// 完全匹配则更新值
+		if plength == len(n.Path) && plength == len(path) {
+			return m.persist(NewLeafNode(path, common.Hash(value)))
+		}
 
-				leaf := &LeafNode{
-					Path:  remainingPath,
-					Value: node.Value,
-				}
-				branch.Children[nibble] = common2.Hash(m.storeNode(leaf))
-			} else {
-				branch.Children[15] = common2.Hash(m.storeNode(&LeafNode{Value: node.Value}))
+		// 部分匹配：在分叉处创建分支节点，分别挂上原叶子和新值
+		branch := NewBranchNode()
+		if err := m.attachValue(branch, n.Path[plength:], n.Value); err != nil {
+			return nil, err
+		}
+		if err := m.attachValue(branch, path[plength:], value); err != nil {
+			return nil, err
+		}
+		return m.wrapExtension(path[:plength], branch)
+
+	case *ExtensionNode:
+		plength := prefixLength(n.Path, path)
+		// 完全匹配则继续处理子节点
+		if plength == len(n.Path) {
+			child, err := m.getNodeByHash(common.Hash(n.Child))
+			if err != nil {
+				return nil, err
 			}
-
-			// 处理新插入的值
-			if len(remainingNibbles) > len(commonPrefix) {
-				nibble := remainingNibbles[len(commonPrefix)]
-				remainingPath := remainingNibbles[len(commonPrefix)+1:]
-
-				leaf := &LeafNode{
-					Path:  remainingPath,
-					Value: valueHash,
-				}
-				branch.Children[nibble] = common2.Hash(m.storeNode(leaf))
-			} else {
-				branch.Children[15] = common2.Hash(m.storeNode(&LeafNode{Value: valueHash}))
+			newChild, err := m.insert(child, path[plength:], value)
+			if err != nil {
+				return nil, err
 			}
+			return m.persist(NewExtensionNode(n.Path, newChild.GetHash()))
+		}
 
-			// 处理共同前缀
-			resultNode := Node(branch)
-			if len(commonPrefix) > 0 {
-				extNode := &ExtensionNode{
-					Path:  commonPrefix,
-					Child: common2.Hash(m.storeNode(branch)),
-				}
-				resultNode = extNode
+		// 部分匹配需要拆分扩展节点
+		branch := NewBranchNode()
+		rest := n.Path[plength:]
+		if len(rest) == 1 {
+			branch.Children[rest[0]] = n.Child
+		} else {
+			ext, err := m.persist(NewExtensionNode(rest[1:], n.Child))
+			if err != nil {
+				return nil, err
 			}
+			branch.Children[rest[0]] = ext.GetHash()
+		}
+		if err := m.attachValue(branch, path[plength:], value); err != nil {
+			return nil, err
+		}
+		return m.wrapExtension(path[:plength], branch)
 
-			// 更新父节点或根节点
-			if item.parent != nil {
-				*item.parentField = m.storeNode(resultNode)
-			} else {
-				rootModified = true
-				newRoot = resultNode
-			}
+	case *BranchNode:
+		branch := *n
+		if len(path) == 0 {
+			branch.Value = value
+			return m.persist(&branch)
+		}
 
-			// 持久化新节点
-			m.db.Put(branch.GetHash().Bytes(), branch.Serialize())
-			if len(commonPrefix) > 0 {
-				m.db.Put(resultNode.GetHash().Bytes(), resultNode.Serialize())
+		var child Node
+		if childHash := n.Children[path[0]]; childHash != (common2.Hash{}) {
+			var err error
+			child, err = m.getNodeByHash(common.Hash(childHash))
+			if err != nil {
+				return nil, err
 			}
+		}
+		newChild, err := m.insert(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		branch.Children[path[0]] = newChild.GetHash()
+		return m.persist(&branch)
 
-		case *ExtensionNode:
-			commonPrefix := findCommonPrefix(node.Path, remainingNibbles)
-
-			// 完全匹配则继续处理子节点
-			if len(commonPrefix) == len(node.Path) {
-				child, err := m.loadNode(common.Hash(node.Child))
-				if err != nil {
-					return err
-				}
-
-				stack = append(stack, stackItem{
-					node:        child,
-					parent:      node,
-					parentField: (*common.Hash)(&node.Child),
-					nibbles:     remainingNibbles[len(commonPrefix):],
-				})
-				continue
-			}
+	default:
+		return nil, fmt.Errorf("MPT: unknown node %T", node)
+	}
+}
 
-			// 部分匹配需要拆分扩展节点
-			branch := &BranchNode{}
+// Delete 删除 key 及其值，key 不存在时不做任何修改。
+// 删除后只剩一个子节点的分支节点会与父子节点合并，
+// 树的结构（以及根哈希）与从未插入过该键时相同。
+func (m *MPT) Delete(key []byte) error {
+	root, err := m.delete(m.Root, convertToNibbles(key))
+	if err != nil {
+		return err
+	}
+	m.Root = root
+	return nil
+}
 
-			// 处理原扩展节点剩余部分
-			if len(node.Path) > len(commonPrefix) {
-				nibble := node.Path[len(commonPrefix)]
-				remainingPath := node.Path[len(commonPrefix)+1:]
+// delete 从以 node 为根的子树中删除 path，返回新的子树根，子树为空时返回 nil
+func (m *MPT) delete(node Node, path []Nibble) (Node, error) {
+	switch n := node.(type) {
+	case nil:
+		return nil, nil
 
-				ext := &ExtensionNode{
-					Path:  remainingPath,
-					Child: node.Child,
-				}
-				branch.Children[nibble] = common2.Hash(m.storeNode(ext))
-			} else {
-				child, err := m.loadNode(common.Hash(node.Child))
-				if err != nil {
-					return err
-				}
-				branch.Children[15] = common2.Hash(m.storeNode(child))
-			}
+	case *LeafNode:
+		if !nibblesEqual(n.Path, path) {
+			return n, nil
+		}
+		return nil, nil
 
-			// 处理新插入的值
-			if len(remainingNibbles) > len(commonPrefix) {
-				nibble := remainingNibbles[len(commonPrefix)]
-				remainingPath := remainingNibbles[len(commonPrefix)+1:]
+	case *ExtensionNode:
+		if len(path) < len(n.Path) || !nibblesEqual(path[:len(n.Path)], n.Path) {
+			return n, nil
+		}
+		child, err := m.getNodeByHash(common.Hash(n.Child))
+		if err != nil {
+			return nil, err
+		}
+		newChild, err := m.delete(child, path[len(n.Path):])
+		if err != nil || newChild == child {
+			return n, err
+		}
+		return m.prependPath(n.Path, newChild)
 
-				leaf := &LeafNode{
-					Path:  remainingPath,
-					Value: valueHash,
-				}
-				branch.Children[nibble] = common2.Hash(m.storeNode(leaf))
-			} else {
-				branch.Children[15] = common2.Hash(m.storeNode(&LeafNode{Value: valueHash}))
+	case *BranchNode:
+		branch := *n
+		if len(path) == 0 {
+			if n.Value == (common2.Hash{}) {
+				return n, nil
 			}
-
-			// 处理共同前缀
-			resultNode := Node(branch)
-			if len(commonPrefix) > 0 {
-				extNode := &ExtensionNode{
-					Path:  commonPrefix,
-					Child: common2.Hash(m.storeNode(branch)),
-				}
-				resultNode = extNode
+			branch.Value = common2.Hash{}
+		} else {
+			childHash := n.Children[path[0]]
+			if childHash == (common2.Hash{}) {
+				return n, nil
 			}
-
-			// 更新父节点或根节点
-			if item.parent != nil {
-				*item.parentField = m.storeNode(resultNode)
-			} else {
-				rootModified = true
-				newRoot = resultNode
+			child, err := m.getNodeByHash(common.Hash(childHash))
+			if err != nil {
+				return nil, err
 			}
-
-			// 持久化新节点
-			m.db.Put(branch.GetHash().Bytes(), branch.Serialize())
-			if len(commonPrefix) > 0 {
-				m.db.Put(resultNode.GetHash().Bytes(), resultNode.Serialize())
+			newChild, err := m.delete(child, path[1:])
+			if err != nil || newChild == child {
+				return n, err
 			}
-
-		case *BranchNode:
-			if len(remainingNibbles) == 0 {
-				// 更新value位置
-				node.Children[15] = common2.Hash(m.storeNode(&LeafNode{Value: valueHash}))
-				m.db.Put(node.GetHash().Bytes(), node.Serialize())
-
-				if item.parent != nil {
-					*item.parentField = m.storeNode(node)
-				} else {
-					rootModified = true
-					newRoot = node
-				}
-				continue
+			branch.Children[path[0]] = common2.Hash{}
+			if newChild != nil {
+				branch.Children[path[0]] = newChild.GetHash()
 			}
+		}
+		return m.collapseBranch(&branch)
 
-			nibble := remainingNibbles[0]
-			var child Node
-			var err error
-
-			if childHash := node.Children[nibble]; !bytes.Equal(childHash[:], make([]byte, 32)) {
-				child, err = m.loadNode(common.Hash(childHash))
-				if err != nil {
-					return err
-				}
-			} else {
-				child = nil
-			}
+	default:
+		return nil, fmt.Errorf("MPT: unknown node %T", node)
+	}
+}
 
-			stack = append(stack, stackItem{
-				node:        child,
-				parent:      node,
-				parentField: (*common.Hash)(&node.Children[nibble]),
-				nibbles:     remainingNibbles[1:],
-			})
+// collapseBranch 持久化删除后的分支节点；只剩一个值或子节点时把它合并为叶子或扩展节点
+func (m *MPT) collapseBranch(branch *BranchNode) (Node, error) {
+	remaining, index := 0, -1
+	if branch.Value != (common2.Hash{}) {
+		remaining++
+	}
+	for i, child := range branch.Children {
+		if child != (common2.Hash{}) {
+			remaining++
+			index = i
 		}
 	}
+	switch {
+	case remaining > 1:
+		return m.persist(branch)
+	case remaining == 0:
+		return nil, nil
+	case index < 0:
+		// 只剩分支自身的值
+		return m.persist(NewLeafNode(nil, common.Hash(branch.Value)))
+	}
+	child, err := m.getNodeByHash(common.Hash(branch.Children[index]))
+	if err != nil {
+		return nil, err
+	}
+	return m.prependPath([]Nibble{Nibble(index)}, child)
+}
 
-	if rootModified {
-		m.Root = newRoot
-		fmt.Printf("Set newRoot successfully: %#v\n", newRoot)
+// prependPath 返回在 node 前加上路径 prefix 后的节点：
+// 叶子与扩展节点直接延长路径，分支节点外包一层扩展节点，node 为 nil 时返回 nil
+func (m *MPT) prependPath(prefix []Nibble, node Node) (Node, error) {
+	switch n := node.(type) {
+	case nil:
+		return nil, nil
+	case *LeafNode:
+		return m.persist(NewLeafNode(append(append([]Nibble{}, prefix...), n.Path...), common.Hash(n.Value)))
+	case *ExtensionNode:
+		return m.persist(NewExtensionNode(append(append([]Nibble{}, prefix...), n.Path...), n.Child))
+	default:
+		return m.persist(NewExtensionNode(prefix, node.GetHash()))
 	}
+}
 
+// attachValue 把剩余路径为 path 的值挂到分支节点上：
+// 路径为空时放在分支自身的值槽，否则创建叶子节点放入对应子槽
+func (m *MPT) attachValue(branch *BranchNode, path []Nibble, value common2.Hash) error {
+	if len(path) == 0 {
+		branch.Value = value
+		return nil
+	}
+	leaf, err := m.persist(NewLeafNode(path[1:], common.Hash(value)))
+	if err != nil {
+		return err
+	}
+	branch.Children[path[0]] = leaf.GetHash()
 	return nil
 }
 
-func (m *MPT) loadNode(hash common.Hash) (Node, error) {
-	node := m.getNodeByHash(hash)
-	if node == nil {
-		return nil, fmt.Errorf("not found")
+// wrapExtension 持久化分支节点，公共前缀非空时再包一层扩展节点
+func (m *MPT) wrapExtension(prefix []Nibble, branch *BranchNode) (Node, error) {
+	node, err := m.persist(branch)
+	if err != nil || len(prefix) == 0 {
+		return node, err
 	}
-	return node, nil
+	return m.persist(NewExtensionNode(prefix, node.GetHash()))
 }
 
-func (m *MPT) storeNode(node Node) common.Hash {
-	hash := node.GetHash()
-	m.db.Put(hash.Bytes(), node.Serialize())
-	return common.Hash(hash)
+// persist 以节点哈希为键写入数据库
+func (m *MPT) persist(node Node) (Node, error) {
+	if err := m.db.Put(node.GetHash().Bytes(), node.Serialize()); err != nil {
+		return nil, err
+	}
+	return node, nil
 }
 
 func NewLeafNode(path []Nibble, valueHash common.Hash) *LeafNode {
 	return &LeafNode{
 		NodeType: LeafNodeType,
-		Path:     path,
+		Path:     append([]Nibble{}, path...),
 		Value:    common2.Hash(valueHash),
 	}
 }
 
-// 通过节点的哈希值从底层存储中加载节点
-func (m *MPT) getNodeByHash(hash common.Hash) Node {
-	if hash == (common.Hash{}) {
+func NewExtensionNode(path []Nibble, child common2.Hash) *ExtensionNode {
+	return &ExtensionNode{
+		NodeType: ExtensionNodeType,
+		Path:     append([]Nibble{}, path...),
+		Child:    child,
+	}
+}
 
-		fmt.Println("getNodeByHash: empty hash")
-		return nil
+func NewBranchNode() *BranchNode {
+	return &BranchNode{NodeType: BranchNodeType}
+}
+
+// 通过节点的哈希值从底层存储中加载节点。
+// 节点缺失返回 ErrMissingNode，其他存储错误原样返回。
+func (m *MPT) getNodeByHash(hash common.Hash) (Node, error) {
+	if hash == (common.Hash{}) {
+		return nil, fmt.Errorf("%w: empty hash", ErrMissingNode)
 	}
-	data, err := m.db.Get(hash.Bytes())
-	if err != nil || len(data) == 0 {
-		fmt.Printf("getNodeByHash: not found data for hash %x, err=%v\n", hash.Bytes(), err)
-		return nil
+	data, err := m.getValue(hash.Bytes())
+	if err != nil {
+		return nil, err
 	}
-	fmt.Printf("getNodeByHash: got data len=%d for hash %x\n", len(data), hash.Bytes())
 
 	var nodeType struct {
 		NodeType NodeType `json:"type"`
 	}
 	if err := json.Unmarshal(data, &nodeType); err != nil {
-		return nil
+		return nil, err
 	}
 
 	switch nodeType.NodeType {
 	case LeafNodeType:
 		var leaf LeafNode
 		if err := json.Unmarshal(data, &leaf); err != nil {
-			return nil
+			return nil, err
 		}
-		return &leaf
+		return &leaf, nil
 	case ExtensionNodeType:
 		var ext ExtensionNode
 		if err := json.Unmarshal(data, &ext); err != nil {
-			return nil
+			return nil, err
 		}
-		return &ext
+		return &ext, nil
 	case BranchNodeType:
 		var branch BranchNode
 		if err := json.Unmarshal(data, &branch); err != nil {
-			return nil
+			return nil, err
 		}
-		return &branch
+		return &branch, nil
 	default:
-		return nil
+		return nil, fmt.Errorf("MPT: unknown node type %d", nodeType.NodeType)
 	}
 }
 
+// getValue 按哈希读取节点或值，存储中不存在时转换为 ErrMissingNode
+func (m *MPT) getValue(hash []byte) ([]byte, error) {
+	data, err := m.db.Get(hash)
+	if errors.Is(err, kvstore.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %x", ErrMissingNode, hash)
+	}
+	return data, err
+}
+
 // prefixLength 计算两个nibble数组的公共前缀长度
 func prefixLength(a, b []Nibble) int {
 	minLen := len(a)
@@ -402,44 +435,37 @@ func (m *MPT) Search(key []byte) ([]byte, error) {
 	for node != nil {
 		switch n := node.(type) {
 		case *LeafNode:
-			fmt.Printf("At LeafNode with path: %v\n", n.Path)
-			fmt.Printf("Searching for nibbles: %v\n", nibbles)
-
-			// 先检查是否完全相等
 			if nibblesEqual(n.Path, nibbles) {
-				val, err := m.db.Get(n.Value[:])
-				if err != nil {
-					return nil, err
-				}
-				return val, nil
+				return m.getValue(n.Value[:])
 			}
-
-			// 如果不等，尝试打印公共前缀长度，帮助调试
-			prefixLen := prefixLength(n.Path, nibbles)
-			fmt.Printf("Prefix length between node path and key: %d\n", prefixLen)
-
 			return nil, MPT_KEY_NOT_FOUND
 
 		case *ExtensionNode:
 			if len(nibbles) < len(n.Path) || !nibblesEqual(nibbles[:len(n.Path)], n.Path) {
 				return nil, MPT_KEY_NOT_FOUND
 			}
-			childNode := m.getNodeByHash(common.Hash(n.Child))
-			if childNode == nil {
-				return nil, MPT_KEY_NOT_FOUND
+			childNode, err := m.getNodeByHash(common.Hash(n.Child))
+			if err != nil {
+				return nil, err
 			}
 			node = childNode
 			nibbles = nibbles[len(n.Path):]
 
 		case *BranchNode:
 			if len(nibbles) == 0 {
-				return m.db.Get(n.Children[15][:]) // value 在 branch 的第 15 槽
+				if n.Value == (common2.Hash{}) {
+					return nil, MPT_KEY_NOT_FOUND
+				}
+				return m.getValue(n.Value[:])
 			}
 			next := n.Children[nibbles[0]]
-			childNode := m.getNodeByHash(common.Hash(next))
-			if childNode == nil {
+			if next == (common2.Hash{}) {
 				return nil, MPT_KEY_NOT_FOUND
 			}
+			childNode, err := m.getNodeByHash(common.Hash(next))
+			if err != nil {
+				return nil, err
+			}
 			node = childNode
 			nibbles = nibbles[1:]
 
@@ -1,14 +1,15 @@
 package trie
 
 import (
+	"CHAIN/kvstore"
 	"bytes"
+	"errors"
 	"fmt"
 	"testing"
 )
 
 func TestSimpleInsertAndSearch(t *testing.T) {
-	// 这里假设有一个内存kvstore实现，满足kvstore.KVStore接口
-	db := NewInMemoryKVStore()
+	db := kvstore.NewMemoryKVStore()
 
 	trie := NewMPT(db)
 
@@ -39,41 +40,185 @@ func TestSimpleInsertAndSearch(t *testing.T) {
 	}
 }
 
-// 以下是一个非常简单的内存KVStore实现，只做排查用
-type InMemoryKVStore struct {
-	store map[string][]byte
-}
+func TestSearchMissingKey(t *testing.T) {
+	trie := NewMPT(kvstore.NewMemoryKVStore())
+	if err := trie.Insert([]byte("key1"), []byte("value1")); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
 
-func (m *InMemoryKVStore) Close() error {
-	//TODO implement me
-	panic("implement me")
+	_, err := trie.Search([]byte("key2"))
+	if !errors.Is(err, kvstore.ErrNotFound) {
+		t.Fatalf("Search of missing key = %v, want ErrNotFound", err)
+	}
 }
 
-func NewInMemoryKVStore() *InMemoryKVStore {
-	return &InMemoryKVStore{
-		store: make(map[string][]byte),
+func TestSearchMissingNode(t *testing.T) {
+	db := kvstore.NewMemoryKVStore()
+	trie := NewMPT(db)
+	value := []byte("value1")
+	if err := trie.Insert([]byte("key1"), value); err != nil {
+		t.Fatalf("Insert failed: %v", err)
 	}
-}
+	// 删除值本身，模拟底层数据损坏
+	db.Delete(Sha3_256(value).Bytes())
 
-func (m *InMemoryKVStore) Get(key []byte) ([]byte, error) {
-	val, ok := m.store[string(key)]
-	if !ok {
-		return nil, fmt.Errorf("not found")
+	_, err := trie.Search([]byte("key1"))
+	if !errors.Is(err, ErrMissingNode) || errors.Is(err, kvstore.ErrNotFound) {
+		t.Fatalf("Search with missing value = %v, want ErrMissingNode", err)
 	}
-	return val, nil
 }
 
-func (m *InMemoryKVStore) Put(key, value []byte) error {
-	m.store[string(key)] = value
-	return nil
+func TestInsertManyKeys(t *testing.T) {
+	keys := map[string]string{
+		"do":    "verb",
+		"dog":   "puppy",
+		"doge":  "coin",
+		"horse": "stallion",
+		"d":     "letter",
+		"cat":   "kitten",
+	}
+	order1 := []string{"do", "dog", "doge", "horse", "d", "cat"}
+	order2 := []string{"cat", "horse", "d", "doge", "dog", "do"}
+
+	build := func(order []string) (*MPT, kvstore.KVStore) {
+		db := kvstore.NewMemoryKVStore()
+		trie := NewMPT(db)
+		for _, k := range order {
+			if err := trie.Insert([]byte(k), []byte(keys[k])); err != nil {
+				t.Fatalf("Insert(%s) failed: %v", k, err)
+			}
+		}
+		return trie, db
+	}
+
+	trie1, db := build(order1)
+	trie2, _ := build(order2)
+	for k, v := range keys {
+		got, err := trie1.Search([]byte(k))
+		if err != nil || string(got) != v {
+			t.Fatalf("Search(%s) = %q, %v; want %q", k, got, err, v)
+		}
+	}
+
+	// 根哈希只取决于内容，与插入顺序无关
+	root1, _ := trie1.RootHash()
+	root2, _ := trie2.RootHash()
+	if root1 != root2 {
+		t.Fatalf("root depends on insertion order: %x != %x", root1, root2)
+	}
+
+	// 更新值改变根哈希
+	if err := trie1.Insert([]byte("dog"), []byte("hound")); err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := trie1.RootHash()
+	if updated == root1 {
+		t.Fatal("root unchanged after update")
+	}
+
+	// 旧根仍然可以从数据库中打开
+	old, err := NewMPTWithRoot(db, root1)
+	if err != nil {
+		t.Fatalf("NewMPTWithRoot failed: %v", err)
+	}
+	got, err := old.Search([]byte("dog"))
+	if err != nil || string(got) != "puppy" {
+		t.Fatalf("old root Search(dog) = %q, %v; want puppy", got, err)
+	}
+	if _, err := old.Search([]byte("dogs")); !errors.Is(err, kvstore.ErrNotFound) {
+		t.Fatalf("Search(dogs) = %v, want ErrNotFound", err)
+	}
 }
 
-func (m *InMemoryKVStore) Delete(key []byte) error {
-	delete(m.store, string(key))
-	return nil
+func TestDelete(t *testing.T) {
+	keys := []string{"do", "dog", "doge", "horse", "d", "cat", "", "dogs", "doghouse"}
+	build := func(keys []string) *MPT {
+		trie := NewMPT(kvstore.NewMemoryKVStore())
+		for _, k := range keys {
+			if err := trie.Insert([]byte(k), []byte("v-"+k)); err != nil {
+				t.Fatalf("Insert(%q) failed: %v", k, err)
+			}
+		}
+		return trie
+	}
+
+	// 依次删除每个键，根哈希与只插入剩余键的树相同
+	trie := build(keys)
+	for i, k := range keys {
+		if err := trie.Delete([]byte(k)); err != nil {
+			t.Fatalf("Delete(%q) failed: %v", k, err)
+		}
+		if _, err := trie.Search([]byte(k)); !errors.Is(err, kvstore.ErrNotFound) {
+			t.Fatalf("Search(%q) after delete = %v, want ErrNotFound", k, err)
+		}
+		got, _ := trie.RootHash()
+		want, _ := build(keys[i+1:]).RootHash()
+		if got != want {
+			t.Fatalf("root after deleting %q = %x, want %x", k, got, want)
+		}
+		for _, rest := range keys[i+1:] {
+			if v, err := trie.Search([]byte(rest)); err != nil || string(v) != "v-"+rest {
+				t.Fatalf("Search(%q) after deleting %q = %q, %v", rest, k, v, err)
+			}
+		}
+	}
+	if trie.Root != nil {
+		t.Fatal("trie not empty after deleting every key")
+	}
+
+	// 删除不存在的键不改变根哈希
+	trie = build(keys)
+	before, _ := trie.RootHash()
+	for _, k := range []string{"dogg", "ca", "zebra", "doge1"} {
+		if err := trie.Delete([]byte(k)); err != nil {
+			t.Fatalf("Delete(%q) failed: %v", k, err)
+		}
+	}
+	if after, _ := trie.RootHash(); after != before {
+		t.Fatal("deleting missing keys changed the root")
+	}
 }
 
-func (m *InMemoryKVStore) Has(key []byte) (bool, error) {
-	_, ok := m.store[string(key)]
-	return ok, nil
+func TestDiffTries(t *testing.T) {
+	db := kvstore.NewMemoryKVStore()
+	oldTrie := NewMPT(db)
+	for _, k := range []string{"do", "dog", "doge", "horse", "cat"} {
+		oldTrie.Insert([]byte(k), []byte("v-"+k))
+	}
+	oldRoot, _ := oldTrie.RootHash()
+
+	newTrie, err := NewMPTWithRoot(db, oldRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newTrie.Insert([]byte("dog"), []byte("changed"))
+	newTrie.Insert([]byte("dogs"), []byte("created"))
+	newTrie.Insert([]byte("zebra"), []byte("created"))
+
+	changes, err := DiffTries(oldTrie, newTrie)
+	if err != nil {
+		t.Fatalf("DiffTries failed: %v", err)
+	}
+	want := []string{
+		"dog: v-dog -> changed",
+		"dogs:  -> created",
+		"zebra:  -> created",
+	}
+	var got []string
+	for _, c := range changes {
+		got = append(got, fmt.Sprintf("%s: %s -> %s", c.Key, c.Old, c.New))
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("DiffTries = %q, want %q", got, want)
+	}
+
+	// 反向比较得到对称结果，相同的树没有差异
+	reverse, _ := DiffTries(newTrie, oldTrie)
+	if len(reverse) != 3 || reverse[1].New != nil {
+		t.Fatalf("reverse diff = %+v", reverse)
+	}
+	same, _ := DiffTries(oldTrie, oldTrie)
+	if len(same) != 0 {
+		t.Fatalf("diff of identical tries = %+v", same)
+	}
 }
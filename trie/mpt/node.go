@@ -57,10 +57,11 @@ func (n *ExtensionNode) GetHash() common.Hash {
 	return Sha3_256(n.Serialize())
 }
 
-// BranchNode 包含16个子节点的分支节点
+// BranchNode 包含16个子节点的分支节点，Value 保存恰好在此结束的键的值哈希
 type BranchNode struct {
 	NodeType NodeType        `json:"type"`
 	Children [16]common.Hash `json:"children"`
+	Value    common.Hash     `json:"value"`
 }
 
 func (n *BranchNode) GetType() NodeType { return BranchNodeType }
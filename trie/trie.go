@@ -2,7 +2,7 @@ package trie
 
 import (
 	"CHAIN/common"
-	"fmt"
+	"CHAIN/kvstore"
 )
 
 type Trie interface {
@@ -41,7 +41,7 @@ func (m *MPT) Insert(key, value []byte) error {
 func (m *MPT) Search(key []byte) ([]byte, error) {
 	val, ok := m.nodes[string(key)]
 	if !ok {
-		return nil, fmt.Errorf("not found")
+		return nil, kvstore.ErrNotFound
 	}
 	return val, nil
 }
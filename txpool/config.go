@@ -0,0 +1,30 @@
+package txpool
+
+import "time"
+
+// Config 交易池的容量限制、替换规则与交易大小限制
+type Config struct {
+	AccountSlots uint64 // 每个账户最多的可执行（pending）交易数
+	GlobalSlots  uint64 // 所有账户的可执行交易总数上限
+	AccountQueue uint64 // 每个账户最多的未来 nonce（queue）交易数
+	GlobalQueue  uint64 // 所有账户的未来 nonce 交易总数上限
+	PriceBump    uint64 // 替换同 nonce 交易时最高费用与小费至少提高的百分比
+	MaxInputSize uint64 // 交易 Input 的最大字节数
+
+	// Lifetime 是账户排队交易的最长存活时间：
+	// 超过该时长没有新交易加入或被提升时，该账户的排队交易全部丢弃，0 表示不过期
+	Lifetime time.Duration
+}
+
+// DefaultConfig 返回默认的交易池配置
+func DefaultConfig() *Config {
+	return &Config{
+		AccountSlots: 16,
+		GlobalSlots:  4096,
+		AccountQueue: 64,
+		GlobalQueue:  1024,
+		PriceBump:    10,
+		MaxInputSize: 128 * 1024,
+		Lifetime:     3 * time.Hour,
+	}
+}
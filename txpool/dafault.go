@@ -1,40 +1,81 @@
 package txpool
 
 import (
+	"CHAIN/BlockChain"
 	"CHAIN/common"
+	"CHAIN/params"
 	"CHAIN/statedb"
+	"bytes"
+	"errors"
 	"fmt"
 	"github.com/ethereum/go-ethereum/trie"
-	"hash"
+	"math/big"
 	"sort"
+	"time"
+)
+
+var (
+	// ErrInvalidSender 表示无法从交易签名中恢复发送者（签名无效、链 ID 不符等）
+	ErrInvalidSender = errors.New("invalid sender")
+	// ErrReplaceUnderpriced 表示替换交易的价格提高幅度不足 Config.PriceBump
+	ErrReplaceUnderpriced = errors.New("replacement transaction underpriced")
+	// ErrAlreadyKnown 表示交易已在交易池中
+	ErrAlreadyKnown = errors.New("already known")
+	// ErrUnknownSender 表示状态中没有交易发送者的账户
+	ErrUnknownSender = errors.New("unknown sender")
+	// ErrNonceTooLow 表示交易 nonce 已被发送者执行过的交易使用
+	ErrNonceTooLow = errors.New("nonce too low")
+	// ErrAccountLimitExceeded 表示发送者的可执行或排队交易已达 Config 中的账户上限
+	ErrAccountLimitExceeded = errors.New("account limit exceeded")
+	// ErrUnderpriced 表示交易池已满，且交易不比池中最便宜的可驱逐交易更贵
+	ErrUnderpriced = errors.New("transaction underpriced")
+	// ErrPoolFull 表示交易池已满，且没有可为该交易驱逐的交易
+	ErrPoolFull = errors.New("txpool is full")
+	// ErrOversizedData 表示交易 Input 超过 Config.MaxInputSize
+	ErrOversizedData = errors.New("oversized data")
+	// ErrGasLimit 表示交易的 Gas 上限超过区块 Gas 上限
+	ErrGasLimit = errors.New("exceeds block gas limit")
+	// ErrIntrinsicGas 表示交易的 Gas 上限低于固有 Gas
+	ErrIntrinsicGas = BlockChain.ErrIntrinsicGas
+	// ErrInsufficientFunds 表示余额不足以支付该交易及发送者其他可执行交易的最大花费
+	ErrInsufficientFunds = BlockChain.ErrInsufficientFunds
+	// ErrNegativeValue 表示交易的金额或价格字段为负数
+	ErrNegativeValue = common.ErrNegativeValue
+	// ErrContractCreation 表示合约创建交易（To 为空），链暂不支持执行
+	ErrContractCreation = BlockChain.ErrContractCreation
 )
 
 type SortedTxs interface { // 定义接口 SortedTxs，用于处理排序后的交易
-	GasPrice() uint64               // GasPrice 方法，返回交易的 Gas 价格
-	Push(tx *common.Transaction)    // Push 方法，向交易列表中添加交易
-	Replace(tx *common.Transaction) // Replace 方法，替换交易列表中的交易
-	Pop() *common.Transaction       // Pop 方法，从交易列表中弹出交易
-	Nonce() uint64                  // Nonce 方法，返回交易的 Nonce 值
+	GasPrice() uint64                                   // GasPrice 方法，返回交易的 Gas 价格
+	Push(tx *common.Transaction)                        // Push 方法，向交易列表中添加交易
+	Replace(tx *common.Transaction) *common.Transaction // Replace 方法，替换交易列表中 nonce 相同的交易，返回被替换的交易
+	Pop() *common.Transaction                           // Pop 方法，从交易列表中弹出交易
+	Nonce() uint64                                      // Nonce 方法，返回交易的 Nonce 值
 }
 
 type pendingTxs []*DefaultSortedTxs // 定义类型 pendingTxs，代表待处理交易的列表集合
 
-// 快排接口实现
-func (p pendingTxs) Len() int           { return len(p) }
-func (p pendingTxs) Less(i, j int) bool { return p[i].GasPrice() < p[j].GasPrice() }
-func (p pendingTxs) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
-
 // 定义结构体 DefaultPool，代表默认交易池
 type DefaultPool struct {
-	State    statedb.StateDB
-	Stat     *trie.StateTrie
-	all      map[hash.Hash]bool
-	txs      pendingTxs
-	pendings map[common.Address]pendingTxs
-	queue    map[common.Address]QueueSortedTxs
+	State       statedb.StateDB
+	Chain       ChainReader         // 重组时按哈希读取区块
+	ChainConfig *params.ChainConfig // 链配置，重置时据此计算下一个区块的基础费用
+	Signer      common.Signer       // 恢复交易发送者所用的签名器
+	Config      *Config             // 容量限制
+	Stat        *trie.StateTrie
+	baseFee     *big.Int                               // 下一个区块的基础费用，用于计算有效小费；London 之前为 nil
+	gasLimit    uint64                                 // 下一个区块的 Gas 上限，0 表示不检查
+	all         map[common.Hash]*common.Transaction    // 池中所有交易（pending 与 queue），按交易哈希索引
+	senders     map[*common.Transaction]common.Address // 池中交易恢复出的发送者
+	txs         pendingTxs
+	pendings    map[common.Address]pendingTxs
+	queue       map[common.Address]QueueSortedTxs
+	beats       map[common.Address]time.Time // 各账户排队交易最近一次加入或被提升的时间
+	popped      map[common.Address]uint64    // 各账户已弹出、尚未反映到状态中的最高 nonce
+	clock       func() time.Time
 }
 type PoolTransaction interface {
-	From() common.Address
+	From() (common.Address, error)
 	Nonce() uint64
 	GasPrice() uint64
 	Hex() string
@@ -42,10 +83,17 @@ type PoolTransaction interface {
 
 func NewDefaultPool(state *trie.StateTrie) *DefaultPool { // 创建并返回一个新的 DefaultPool 实例
 	return &DefaultPool{
-		Stat:     state,
-		all:      make(map[hash.Hash]bool),
-		pendings: make(map[common.Address]pendingTxs),
-		queue:    make(map[common.Address]QueueSortedTxs),
+		ChainConfig: params.DefaultChainConfig,
+		Signer:      common.NewEIP155Signer(params.DefaultChainConfig.ChainID),
+		Config:      DefaultConfig(),
+		Stat:        state,
+		all:         make(map[common.Hash]*common.Transaction),
+		senders:     make(map[*common.Transaction]common.Address),
+		pendings:    make(map[common.Address]pendingTxs),
+		queue:       make(map[common.Address]QueueSortedTxs),
+		beats:       make(map[common.Address]time.Time),
+		popped:      make(map[common.Address]uint64),
+		clock:       time.Now,
 	}
 }
 
@@ -61,12 +109,16 @@ func (sorted *DefaultSortedTxs) Push(tx *common.Transaction) {
 	*sorted = append(*sorted, tx)
 }
 
-func (sorted DefaultSortedTxs) Replace(tx *common.Transaction) {
+// Replace 用 tx 替换 nonce 相同的交易并返回被替换的交易，没有时返回 nil。
+// 价格是否足够由交易池检查
+func (sorted DefaultSortedTxs) Replace(tx *common.Transaction) *common.Transaction {
 	for key, value := range sorted {
-		if value.Nonce == tx.Nonce && tx.GasPriceUint64() > 0 {
+		if value.Nonce == tx.Nonce {
 			sorted[key] = tx
+			return value
 		}
 	}
+	return nil
 }
 
 func (sorted *DefaultSortedTxs) Pop() *common.Transaction {
@@ -86,6 +138,31 @@ func (sorted DefaultSortedTxs) GasPrice() uint64 {
 	return sorted[0].GasPriceUint64()
 }
 
+// EffectiveTip 返回首笔交易在给定基础费用下的有效小费，
+// 最高价格低于基础费用时为负值，排在最后
+func (sorted DefaultSortedTxs) EffectiveTip(baseFee *big.Int) *big.Int {
+	tip, _ := sorted[0].EffectiveGasTip(baseFee)
+	return tip
+}
+
+// SetBaseFee 设置下一个区块的基础费用，并按新的有效小费重新排序
+func (pool *DefaultPool) SetBaseFee(baseFee *big.Int) {
+	pool.baseFee = baseFee
+	pool.sortTxs()
+}
+
+// SetGasLimit 设置下一个区块的 Gas 上限，Gas 上限超过它的交易不再被接受
+func (pool *DefaultPool) SetGasLimit(gasLimit uint64) {
+	pool.gasLimit = gasLimit
+}
+
+// sortTxs 按有效小费从高到低排列待打包的交易组
+func (pool *DefaultPool) sortTxs() {
+	sort.SliceStable(pool.txs, func(i, j int) bool {
+		return pool.txs[i].EffectiveTip(pool.baseFee).Cmp(pool.txs[j].EffectiveTip(pool.baseFee)) > 0
+	})
+}
+
 func (pool *DefaultPool) PrintfPool() {
 	for _, txs := range pool.txs {
 		fmt.Println("tx block")
@@ -107,102 +184,344 @@ func (pool *DefaultPool) PrintfPool() {
 	}
 }
 
-func (pool *DefaultPool) SetStatRoot(root hash.Hash) {
-	// 设置状态树的根哈希值（占位）
-	// pool.Stat.SetRoot(root)
+// validateTx 校验不依赖状态的交易规则（金额、大小、Gas）和签名，返回恢复出的发送者。
+// 交易类型不被当前签名器支持（如分叉前的类型化交易）或链无法执行的合约创建交易同样拒绝
+func (pool *DefaultPool) validateTx(tx *common.Transaction) (common.Address, error) {
+	if err := tx.ValidateValues(); err != nil {
+		return common.Address{}, err
+	}
+	if tx.To == nil {
+		return common.Address{}, ErrContractCreation
+	}
+	if size := uint64(len(tx.Input)); size > pool.Config.MaxInputSize {
+		return common.Address{}, fmt.Errorf("%w: %d bytes, limit %d", ErrOversizedData, size, pool.Config.MaxInputSize)
+	}
+	if pool.gasLimit > 0 && tx.GasLimit > pool.gasLimit {
+		return common.Address{}, fmt.Errorf("%w: have %d, block %d", ErrGasLimit, tx.GasLimit, pool.gasLimit)
+	}
+	if gas := BlockChain.IntrinsicGas(tx.Input, tx.AccessList); tx.GasLimit < gas {
+		return common.Address{}, fmt.Errorf("%w: have %d, want %d", ErrIntrinsicGas, tx.GasLimit, gas)
+	}
+	from, err := common.Sender(pool.Signer, tx)
+	if err != nil {
+		// 保留原始错误，调用方可以区分 common.ErrTxTypeNotSupported 等原因
+		return common.Address{}, fmt.Errorf("%w: %w", ErrInvalidSender, err)
+	}
+	return from, nil
 }
 
-func (pool *DefaultPool) NewTx(tx *common.Transaction) {
-	account := pool.State.Load(tx.From())
+// validateFunds 检查发送者余额能否同时支付 tx 与其他可执行交易的最大花费，
+// 被 tx 替换的同 nonce 交易不计入
+func (pool *DefaultPool) validateFunds(from common.Address, tx *common.Transaction) error {
+	cost := tx.Cost()
+	for _, blk := range pool.pendings[from] {
+		for _, pending := range *blk {
+			if pending.Nonce != tx.Nonce {
+				cost.Add(cost, pending.Cost())
+			}
+		}
+	}
+	if balance := pool.State.GetBalance(from); balance.Cmp(cost) < 0 {
+		return fmt.Errorf("%w: address %s have %v want %v", ErrInsufficientFunds, from, balance, cost)
+	}
+	return nil
+}
+
+// NewTx 把交易加入交易池，返回拒绝的原因；nil 表示交易已加入可执行交易或排队队列
+func (pool *DefaultPool) NewTx(tx *common.Transaction) error {
+	pool.removeStaleQueues()
+
+	// 负数字段无法编码，必须在计算哈希和恢复签名之前拒绝
+	if err := tx.ValidateValues(); err != nil {
+		return err
+	}
+	raw, err := tx.Hash()
+	if err != nil {
+		return err
+	}
+	if hash := common.BytesToHash(raw); pool.Has(hash) {
+		// 已知交易直接拒绝，不必再恢复签名
+		return fmt.Errorf("%w: %s", ErrAlreadyKnown, hash)
+	}
+	from, err := pool.validateTx(tx)
+	if err != nil {
+		return err
+	}
+
+	account := pool.State.Load(from)
 	if account == nil {
-		// 没有账户信息，拒绝该交易
-		return
+		return fmt.Errorf("%w: %s", ErrUnknownSender, from)
 	}
 
 	if account.Nonce >= tx.Nonce {
-		return
+		return fmt.Errorf("%w: address %s, tx: %d state: %d", ErrNonceTooLow, from, tx.Nonce, account.Nonce+1)
+	}
+	// 已弹出的交易尚未反映到状态中，其 nonce 同样不能再使用
+	if popped, ok := pool.popped[from]; ok && tx.Nonce <= popped {
+		return fmt.Errorf("%w: address %s, nonce %d already popped", ErrNonceTooLow, from, tx.Nonce)
+	}
+	if err := pool.validateFunds(from, tx); err != nil {
+		return err
 	}
 
-	nonce := account.Nonce
-	blks := pool.pendings[tx.From()]
+	nonce := max(account.Nonce, pool.popped[from])
+	blks := pool.pendings[from]
 	if len(blks) > 0 {
 		last := blks[len(blks)-1]
 		nonce = last.Nonce()
 	}
 
 	if tx.Nonce > nonce+1 {
-		pool.addQueueTx(tx)
+		return pool.addQueueTx(from, tx)
 	} else if tx.Nonce == nonce+1 {
-		pool.pushPendingTx(tx)
-	} else {
-		pool.replacePendingTx(tx)
+		return pool.addPendingTx(from, tx)
 	}
+	return pool.replacePendingTx(from, tx)
 }
 
-func (pool *DefaultPool) replacePendingTx(tx *common.Transaction) {
-	blks := pool.pendings[tx.From()]
-	for _, blk := range blks {
-		if blk.Nonce() >= tx.Nonce {
+// AddTxs 依次把 txs 加入交易池，返回与 txs 一一对应的结果，nil 表示该交易已加入
+func (pool *DefaultPool) AddTxs(txs []*common.Transaction) []error {
+	errs := make([]error, len(txs))
+	for i, tx := range txs {
+		errs[i] = pool.NewTx(tx)
+	}
+	return errs
+}
+
+// replacePendingTx 用 tx 替换发送者 nonce 相同的可执行交易，
+// 价格提高不足 Config.PriceBump 时返回 ErrReplaceUnderpriced；
+// 池中已没有该 nonce 的交易（已被 Pop）时返回 ErrNonceTooLow
+func (pool *DefaultPool) replacePendingTx(from common.Address, tx *common.Transaction) error {
+	for _, blk := range pool.pendings[from] {
+		for _, old := range *blk {
+			if old.Nonce != tx.Nonce {
+				continue
+			}
+			if !pool.priceBumped(old, tx) {
+				return fmt.Errorf("%w: nonce %d", ErrReplaceUnderpriced, tx.Nonce)
+			}
 			blk.Replace(tx)
-			sort.Sort(blks)
-			break
+			pool.untrack(old)
+			pool.track(from, tx)
+			pool.regroupPending(from)
+			return nil
 		}
 	}
+	// 该 nonce 的交易已被弹出打包，但尚未反映到状态中
+	return fmt.Errorf("%w: address %s, nonce %d already popped", ErrNonceTooLow, from, tx.Nonce)
 }
 
-func (pool *DefaultPool) pushPendingTx(tx *common.Transaction) {
-	blks := pool.pendings[tx.From()]
-	if len(blks) == 0 {
-		blk := &DefaultSortedTxs{tx}
-		blks = append(blks, blk)
-		pool.pendings[tx.From()] = blks
-		pool.txs = append(pool.txs, blk)
-		sort.Sort(pool.txs)
-	} else {
+// regroupPending 按当前小费重新划分发送者的可执行交易组并重新排序，
+// 替换交易后各组小费递减的顺序可能被打破
+func (pool *DefaultPool) regroupPending(from common.Address) {
+	for _, tx := range pool.takePending(from) {
+		pool.appendPendingTx(from, tx)
+	}
+	pool.sortTxs()
+}
+
+// takePending 移除发送者的全部可执行交易组，按 nonce 顺序返回其中的交易。
+// 交易仍保留在 all 中，由调用方重新放回或删除
+func (pool *DefaultPool) takePending(from common.Address) []*common.Transaction {
+	var txs []*common.Transaction
+	for _, blk := range pool.pendings[from] {
+		txs = append(txs, *blk...)
+		for i, cur := range pool.txs {
+			if cur == blk {
+				pool.txs = append(pool.txs[:i], pool.txs[i+1:]...)
+				break
+			}
+		}
+	}
+	delete(pool.pendings, from)
+	return txs
+}
+
+// priceBumped 判断 tx 的最高费用和小费是否都比 old 高出至少 Config.PriceBump 百分比
+func (pool *DefaultPool) priceBumped(old, tx *common.Transaction) bool {
+	bumped := func(oldPrice, newPrice *big.Int) bool {
+		if newPrice.Cmp(oldPrice) <= 0 {
+			return false
+		}
+		// newPrice * 100 >= oldPrice * (100 + PriceBump)
+		threshold := new(big.Int).Mul(oldPrice, new(big.Int).SetUint64(100+pool.Config.PriceBump))
+		return new(big.Int).Mul(newPrice, big.NewInt(100)).Cmp(threshold) >= 0
+	}
+	return bumped(old.FeeCap(), tx.FeeCap()) && bumped(old.TipCap(), tx.TipCap())
+}
+
+// addPendingTx 在容量允许时把 tx 加入可执行交易，并提升该账户随后连续 nonce 的排队交易
+func (pool *DefaultPool) addPendingTx(from common.Address, tx *common.Transaction) error {
+	if err := pool.reservePendingSlot(from, tx); err != nil {
+		return err
+	}
+	pool.pushPendingTx(from, tx)
+	pool.promoteQueue(from, tx.Nonce)
+	return nil
+}
+
+// pushPendingTx 把 tx 追加到发送者的可执行交易末尾，不检查容量
+func (pool *DefaultPool) pushPendingTx(from common.Address, tx *common.Transaction) {
+	if pool.appendPendingTx(from, tx) {
+		pool.sortTxs()
+	}
+}
+
+// appendPendingTx 与 pushPendingTx 相同但不重新排序，新开一组时返回 true，
+// 由调用方在批量加入后统一排序
+func (pool *DefaultPool) appendPendingTx(from common.Address, tx *common.Transaction) bool {
+	pool.track(from, tx)
+	blks := pool.pendings[from]
+	if len(blks) > 0 {
+		// 小费不低于最后一组的交易并入该组，否则新开一组；
+		// 同一发送者的各组小费递减，按小费排序时仍保持 nonce 顺序
 		last := blks[len(blks)-1]
-		if last.GasPrice() <= tx.GasPriceUint64() {
+		tip, _ := tx.EffectiveGasTip(pool.baseFee)
+		if last.EffectiveTip(pool.baseFee).Cmp(tip) <= 0 {
 			*last = append(*last, tx)
-		} else {
-			blk := &DefaultSortedTxs{tx}
-			blks = append(blks, blk)
-			pool.pendings[tx.From()] = blks
-			pool.txs = append(pool.txs, blk)
-			sort.Sort(pool.txs)
+			return false
 		}
 	}
+	blk := &DefaultSortedTxs{tx}
+	pool.pendings[from] = append(blks, blk)
+	pool.txs = append(pool.txs, blk)
+	return true
+}
 
-	queueTxs := pool.queue[tx.From()]
-	nonce := tx.Nonce
-	for i := 0; i < len(queueTxs); i++ {
-		if queueTxs[i].Nonce == nonce+1 {
-			nonce++
-			nextTx := queueTxs[i]
-			queueTxs = append(queueTxs[:i], queueTxs[i+1:]...)
-			i--
-			pool.queue[tx.From()] = queueTxs
-			pool.pushPendingTx(nextTx)
+// promoteQueue 把 from 的排队交易中紧接 nonce 的连续交易移入可执行交易，
+// 可执行交易已满时剩余交易继续排队
+func (pool *DefaultPool) promoteQueue(from common.Address, nonce uint64) {
+	for {
+		queueTxs := pool.queue[from]
+		if len(queueTxs) > 0 && queueTxs[0].Nonce <= nonce {
+			// 与可执行交易 nonce 重复的排队交易已经过时
+			pool.removeQueueTx(queueTxs[0])
+			continue
+		}
+		if len(queueTxs) == 0 || queueTxs[0].Nonce != nonce+1 || pool.reservePendingSlot(from, queueTxs[0]) != nil {
+			break
+		}
+		next := queueTxs[0]
+		pool.removeQueueTx(next)
+		pool.pushPendingTx(from, next)
+		nonce++
+		if _, ok := pool.queue[from]; ok {
+			pool.beats[from] = pool.clock()
 		}
 	}
 }
 
-func (pool *DefaultPool) addQueueTx(tx *common.Transaction) {
-	txs := pool.queue[tx.From()]
-	txs = append(txs, tx)
+// addQueueTx 在容量允许时把未来 nonce 的交易加入排队队列，
+// 已有相同 nonce 的排队交易时按替换规则处理
+func (pool *DefaultPool) addQueueTx(from common.Address, tx *common.Transaction) error {
+	txs := pool.queue[from]
+	for i, old := range txs {
+		if old.Nonce != tx.Nonce {
+			continue
+		}
+		if !pool.priceBumped(old, tx) {
+			return fmt.Errorf("%w: nonce %d", ErrReplaceUnderpriced, tx.Nonce)
+		}
+		txs[i] = tx
+		pool.untrack(old)
+		pool.track(from, tx)
+		pool.beats[from] = pool.clock()
+		return nil
+	}
+
+	if uint64(len(txs)) >= pool.Config.AccountQueue {
+		return fmt.Errorf("%w: address %s has %d queued", ErrAccountLimitExceeded, from, len(txs))
+	}
+	if uint64(pool.queueLen()) >= pool.Config.GlobalQueue {
+		if err := pool.evictQueue(tx); err != nil {
+			return err
+		}
+	}
+	txs = append(pool.queue[from], tx)
 	sort.Slice(txs, func(i, j int) bool {
 		return txs[i].Nonce < txs[j].Nonce
 	})
-	pool.queue[tx.From()] = txs
+	pool.queue[from] = txs
+	pool.track(from, tx)
+	pool.beats[from] = pool.clock()
+	return nil
 }
 
-func (pool *DefaultPool) Pop() *common.Transaction {
-	if len(pool.txs) == 0 || pool.txs[0] == nil {
-		return nil
+// Has 判断交易池中是否有该哈希的交易
+func (pool *DefaultPool) Has(hash common.Hash) bool {
+	_, ok := pool.all[hash]
+	return ok
+}
+
+// Get 返回交易池中该哈希的交易，不存在时返回 nil
+func (pool *DefaultPool) Get(hash common.Hash) *common.Transaction {
+	return pool.all[hash]
+}
+
+// Remove 从交易池中删除该哈希的交易，返回交易是否存在。
+// 删除可执行交易后，同一发送者 nonce 更大的交易不再连续，按排队队列的容量限制移回排队队列
+func (pool *DefaultPool) Remove(hash common.Hash) bool {
+	tx, ok := pool.all[hash]
+	if !ok {
+		return false
+	}
+	from := pool.senders[tx]
+	for _, queued := range pool.queue[from] {
+		if queued == tx {
+			pool.removeQueueTx(tx)
+			return true
+		}
+	}
+
+	pool.untrack(tx)
+	for _, pending := range pool.takePending(from) {
+		switch {
+		case pending.Nonce < tx.Nonce:
+			pool.appendPendingTx(from, pending)
+		case pending.Nonce > tx.Nonce:
+			// 与新的排队交易一样受队列容量限制，无法排队的交易被丢弃
+			if err := pool.addQueueTx(from, pending); err != nil {
+				pool.untrack(pending)
+			}
+		}
 	}
-	tx := pool.txs[0].Pop()
-	if len(*pool.txs[0]) == 0 {
-		pool.txs = pool.txs[1:]
+	pool.sortTxs()
+	return true
+}
+
+// Pop 弹出有效小费最高的可执行交易。
+// 只从各发送者的第一组中选取，保证同一发送者的交易按 nonce 顺序弹出。
+// 最高价格低于当前基础费用的交易不会被弹出。
+// 弹出的 nonce 被记录下来，在 Reset 之前不再接受该 nonce 及更小 nonce 的交易
+func (pool *DefaultPool) Pop() *common.Transaction {
+	pool.removeStaleQueues()
+
+	for i, blk := range pool.txs {
+		from := pool.senders[(*blk)[0]]
+		blks := pool.pendings[from]
+		if len(blks) == 0 || blks[0] != blk {
+			continue
+		}
+		// 最高价格低于基础费用的交易无法打包，留在池中等基础费用下降
+		if _, err := (*blk)[0].EffectiveGasTip(pool.baseFee); err != nil {
+			continue
+		}
+		tx := blk.Pop()
+		if len(*blk) == 0 {
+			pool.txs = append(pool.txs[:i], pool.txs[i+1:]...)
+			if len(blks) == 1 {
+				delete(pool.pendings, from)
+			} else {
+				pool.pendings[from] = blks[1:]
+			}
+		} else {
+			pool.sortTxs()
+		}
+		pool.untrack(tx)
+		pool.popped[from] = tx.Nonce
+		return tx
 	}
-	return tx
+	return nil
 }
 
 func (pool *DefaultPool) NotifyTxEvent(txs []*common.Transaction) {
@@ -214,3 +533,188 @@ func (pool *DefaultPool) NotifyTxEvent(txs []*common.Transaction) {
 		)
 	}
 }
+
+// reservePendingSlot 为 tx 腾出一个可执行交易的位置：账户已满时返回 ErrAccountLimitExceeded；
+// 全局已满时尝试驱逐一笔比 tx 便宜的可执行交易
+func (pool *DefaultPool) reservePendingSlot(from common.Address, tx *common.Transaction) error {
+	if n := pool.pendingLen(from); uint64(n) >= pool.Config.AccountSlots {
+		return fmt.Errorf("%w: address %s has %d pending", ErrAccountLimitExceeded, from, n)
+	}
+	if uint64(pool.txs.len()) >= pool.Config.GlobalSlots {
+		return pool.evictPending(from, tx)
+	}
+	return nil
+}
+
+// evictPending 在其他账户的最后一笔可执行交易中驱逐有效小费最低的一笔，并列时按 evictFirst 确定。
+// 只驱逐末尾的交易，不会在账户的 nonce 序列中留下空洞；
+// 没有可驱逐的交易时返回 ErrPoolFull，最便宜的一笔也不比 tx 便宜时返回 ErrUnderpriced
+func (pool *DefaultPool) evictPending(from common.Address, tx *common.Transaction) error {
+	var victim *common.Transaction
+	for addr, blks := range pool.pendings {
+		if addr == from {
+			continue
+		}
+		last := *blks[len(blks)-1]
+		if candidate := last[len(last)-1]; victim == nil || pool.evictFirst(candidate, victim) {
+			victim = candidate
+		}
+	}
+	if err := pool.checkEvictable(victim, tx); err != nil {
+		return err
+	}
+	pool.removeLastPending(pool.senders[victim])
+	return nil
+}
+
+// evictQueue 驱逐所有排队交易中有效小费最低的一笔，并列时按 evictFirst 确定，错误与 evictPending 相同
+func (pool *DefaultPool) evictQueue(tx *common.Transaction) error {
+	var victim *common.Transaction
+	for _, txs := range pool.queue {
+		for _, candidate := range txs {
+			if victim == nil || pool.evictFirst(candidate, victim) {
+				victim = candidate
+			}
+		}
+	}
+	if err := pool.checkEvictable(victim, tx); err != nil {
+		return err
+	}
+	pool.removeQueueTx(victim)
+	return nil
+}
+
+// evictFirst 判断驱逐时 a 是否排在 b 之前：有效小费低的优先；
+// 小费相同时 nonce 大的优先，再相同时发送者地址大的优先，使驱逐结果不依赖 map 的遍历顺序
+func (pool *DefaultPool) evictFirst(a, b *common.Transaction) bool {
+	tipA, _ := a.EffectiveGasTip(pool.baseFee)
+	tipB, _ := b.EffectiveGasTip(pool.baseFee)
+	if c := tipA.Cmp(tipB); c != 0 {
+		return c < 0
+	}
+	if a.Nonce != b.Nonce {
+		return a.Nonce > b.Nonce
+	}
+	fromA, fromB := pool.senders[a], pool.senders[b]
+	return bytes.Compare(fromA[:], fromB[:]) > 0
+}
+
+// checkEvictable 判断能否驱逐 victim 为 tx 腾出位置，victim 为 nil 表示没有候选交易
+func (pool *DefaultPool) checkEvictable(victim, tx *common.Transaction) error {
+	if victim == nil {
+		return ErrPoolFull
+	}
+	if !pool.cheaper(victim, tx) {
+		tip, _ := tx.EffectiveGasTip(pool.baseFee)
+		return fmt.Errorf("%w: tip %v", ErrUnderpriced, tip)
+	}
+	return nil
+}
+
+// removeLastPending 删除 from 的最后一笔可执行交易
+func (pool *DefaultPool) removeLastPending(from common.Address) {
+	blks := pool.pendings[from]
+	last := blks[len(blks)-1]
+	tx := (*last)[len(*last)-1]
+	*last = (*last)[:len(*last)-1]
+	pool.untrack(tx)
+	if len(*last) > 0 {
+		return
+	}
+	for i, blk := range pool.txs {
+		if blk == last {
+			pool.txs = append(pool.txs[:i], pool.txs[i+1:]...)
+			break
+		}
+	}
+	if len(blks) == 1 {
+		delete(pool.pendings, from)
+	} else {
+		pool.pendings[from] = blks[:len(blks)-1]
+	}
+}
+
+// removeQueueTx 从排队队列中删除 tx，账户队列为空时一并清除其时间记录
+func (pool *DefaultPool) removeQueueTx(tx *common.Transaction) {
+	from := pool.senders[tx]
+	txs := pool.queue[from]
+	for i, queued := range txs {
+		if queued == tx {
+			txs = append(txs[:i], txs[i+1:]...)
+			break
+		}
+	}
+	pool.untrack(tx)
+	if len(txs) == 0 {
+		delete(pool.queue, from)
+		delete(pool.beats, from)
+		return
+	}
+	pool.queue[from] = txs
+}
+
+// removeStaleQueues 丢弃超过 Config.Lifetime 没有进展的账户的全部排队交易
+func (pool *DefaultPool) removeStaleQueues() {
+	if pool.Config.Lifetime <= 0 {
+		return
+	}
+	now := pool.clock()
+	for from, beat := range pool.beats {
+		if now.Sub(beat) <= pool.Config.Lifetime {
+			continue
+		}
+		for _, tx := range pool.queue[from] {
+			pool.untrack(tx)
+		}
+		delete(pool.queue, from)
+		delete(pool.beats, from)
+	}
+}
+
+// pendingLen 返回 from 的可执行交易数
+func (pool *DefaultPool) pendingLen(from common.Address) int {
+	return pool.pendings[from].len()
+}
+
+// len 返回各组交易数之和
+func (blks pendingTxs) len() int {
+	n := 0
+	for _, blk := range blks {
+		n += len(*blk)
+	}
+	return n
+}
+
+// queueLen 返回所有账户的排队交易数
+func (pool *DefaultPool) queueLen() int {
+	n := 0
+	for _, txs := range pool.queue {
+		n += len(txs)
+	}
+	return n
+}
+
+// cheaper 判断 a 在当前基础费用下的有效小费是否低于 b
+func (pool *DefaultPool) cheaper(a, b *common.Transaction) bool {
+	tipA, _ := a.EffectiveGasTip(pool.baseFee)
+	tipB, _ := b.EffectiveGasTip(pool.baseFee)
+	return tipA.Cmp(tipB) < 0
+}
+
+// track 把 tx 记入交易池索引。发送者保存在交易池中，不写回调用方的交易
+func (pool *DefaultPool) track(from common.Address, tx *common.Transaction) {
+	pool.all[txHash(tx)] = tx
+	pool.senders[tx] = from
+}
+
+// untrack 从交易池索引中删除 tx
+func (pool *DefaultPool) untrack(tx *common.Transaction) {
+	delete(pool.all, txHash(tx))
+	delete(pool.senders, tx)
+}
+
+// txHash 返回池中交易或区块交易的哈希，这些交易都已通过校验，编码不会失败
+func txHash(tx *common.Transaction) common.Hash {
+	hash, _ := tx.Hash()
+	return common.BytesToHash(hash)
+}
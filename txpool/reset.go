@@ -0,0 +1,203 @@
+package txpool
+
+import (
+	"CHAIN/BlockChain"
+	"CHAIN/common"
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// ErrUnknownAncestor 表示重组时找不到旧链头与新链头的共同祖先，被丢弃区块中的交易无法重新加入
+var ErrUnknownAncestor = errors.New("unknown ancestor")
+
+// ChainReader 是交易池处理重组时读取区块所需的链接口，*BlockChain.Chain 实现了该接口。
+// 重组时 Reset 沿旧链头回溯，GetBlock 必须也能返回被丢弃分支（侧链）上的区块
+type ChainReader interface {
+	// GetBlock 返回哈希为 hash 的区块（规范链或侧链），不存在时返回 nil
+	GetBlock(hash []byte) *BlockChain.Block
+}
+
+var _ ChainReader = (*BlockChain.Chain)(nil)
+
+// Reset 把交易池切换到新链头 newHead：删除已打包的交易，按新状态重新读取 nonce 和余额，
+// 重新计算下一个区块的 Gas 上限与基础费用，并重新校验池中的全部交易。nonce 已被执行或余额不足的交易被丢弃，
+// 其后不再连续的可执行交易降级到排队队列，变得连续的排队交易被提升为可执行交易。
+// oldHead 与 newHead 不在同一条链上时（重组），被丢弃区块中未被新链打包的交易重新加入交易池。
+// oldHead 为 nil 时只按新状态重新校验。
+// 找不到共同祖先时仍按新状态重新校验，但返回 ErrUnknownAncestor
+func (pool *DefaultPool) Reset(oldHead, newHead *BlockChain.Block) error {
+	var (
+		reinject []*common.Transaction
+		included = make(map[common.Hash]bool)
+		reorgErr error
+	)
+	if oldHead != nil {
+		discarded, added, err := pool.reorgTxs(oldHead, newHead)
+		for _, tx := range added {
+			included[txHash(tx)] = true
+		}
+		for _, tx := range discarded {
+			if !included[txHash(tx)] {
+				reinject = append(reinject, tx)
+			}
+		}
+		reorgErr = err
+	}
+
+	if err := pool.State.SetRoot(newHead.StateRoot); err != nil {
+		return err
+	}
+	// 新状态已反映被打包的交易，未被打包的已弹出交易不再保留
+	pool.popped = make(map[common.Address]uint64)
+	pool.gasLimit = newHead.GasLimit
+	pool.baseFee = nil
+	if pool.ChainConfig.IsLondon(newHead.Index + 1) {
+		pool.baseFee = BlockChain.CalcBaseFee(pool.ChainConfig, newHead)
+	}
+
+	// 清空交易池后按发送者重新整理：池中原有交易排在重新注入的交易之前，同 nonce 时保留原交易，
+	// 无法再执行的交易直接丢弃；最后统一排序一次
+	byFrom := make(map[common.Address][]*common.Transaction)
+	for _, tx := range append(pool.drain(), reinject...) {
+		if included[txHash(tx)] {
+			continue
+		}
+		from, err := pool.validateTx(tx)
+		if err != nil {
+			continue
+		}
+		byFrom[from] = append(byFrom[from], tx)
+	}
+	senders := make([]common.Address, 0, len(byFrom))
+	for from := range byFrom {
+		senders = append(senders, from)
+	}
+	sort.Slice(senders, func(i, j int) bool {
+		return bytes.Compare(senders[i][:], senders[j][:]) < 0
+	})
+	slots, queueSlots := pool.Config.GlobalSlots, pool.Config.GlobalQueue
+	for _, from := range senders {
+		pending, queued := pool.readmit(from, byFrom[from], slots, queueSlots)
+		slots -= pending
+		queueSlots -= queued
+	}
+	pool.sortTxs()
+
+	// 重新加入不应延长排队交易的存活时间
+	beats, now := pool.beats, pool.clock()
+	pool.beats = make(map[common.Address]time.Time)
+	for from := range pool.queue {
+		if beat, ok := beats[from]; ok {
+			pool.beats[from] = beat
+		} else {
+			pool.beats[from] = now
+		}
+	}
+	return reorgErr
+}
+
+// reorgTxs 从 oldHead 和 newHead 分别回溯到共同祖先，
+// 返回旧链上被丢弃区块的交易与新链上新增区块的交易。
+// newHead 直接接在 oldHead 之后时 discarded 为空，added 即 newHead 的交易
+func (pool *DefaultPool) reorgTxs(oldHead, newHead *BlockChain.Block) (discarded, added []*common.Transaction, err error) {
+	if bytes.Equal(newHead.PrevHash, oldHead.Hash) {
+		// 直接导入子区块是最常见的情况，不需要读取链
+		return nil, newHead.Transactions, nil
+	}
+	rem, add := oldHead, newHead
+	for rem.Index > add.Index {
+		discarded = append(discarded, rem.Transactions...)
+		if rem, err = pool.getBlock(rem.PrevHash); err != nil {
+			return nil, added, err
+		}
+	}
+	for add.Index > rem.Index {
+		added = append(added, add.Transactions...)
+		if add, err = pool.getBlock(add.PrevHash); err != nil {
+			return nil, added, err
+		}
+	}
+	for !bytes.Equal(rem.Hash, add.Hash) {
+		discarded = append(discarded, rem.Transactions...)
+		added = append(added, add.Transactions...)
+		if rem, err = pool.getBlock(rem.PrevHash); err != nil {
+			return nil, added, err
+		}
+		if add, err = pool.getBlock(add.PrevHash); err != nil {
+			return nil, added, err
+		}
+	}
+	return discarded, added, nil
+}
+
+// getBlock 通过 Chain 读取区块，找不到时返回 ErrUnknownAncestor
+func (pool *DefaultPool) getBlock(hash []byte) (*BlockChain.Block, error) {
+	if pool.Chain != nil {
+		if block := pool.Chain.GetBlock(hash); block != nil {
+			return block, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: block %x", ErrUnknownAncestor, hash)
+}
+
+// readmit 按新状态重新加入 from 的交易，slots 与 queueSlots 是全局剩余的可执行与排队容量。
+// 紧接账户 nonce、余额足够且容量允许的连续交易成为可执行交易，其余进入排队队列；
+// nonce 已执行、余额不足或超出容量的交易被丢弃。不重新排序 pool.txs，返回加入的可执行与排队交易数
+func (pool *DefaultPool) readmit(from common.Address, txs []*common.Transaction, slots, queueSlots uint64) (pending, queued uint64) {
+	account := pool.State.Load(from)
+	if account == nil {
+		return 0, 0
+	}
+	sort.SliceStable(txs, func(i, j int) bool {
+		return txs[i].Nonce < txs[j].Nonce
+	})
+	var (
+		balance = pool.State.GetBalance(from)
+		nonce   = account.Nonce
+		cost    = new(big.Int) // 可执行交易的累计最大花费
+		queue   QueueSortedTxs
+	)
+	for i, tx := range txs {
+		if tx.Nonce <= account.Nonce || (i > 0 && tx.Nonce == txs[i-1].Nonce) {
+			continue
+		}
+		total := new(big.Int).Add(cost, tx.Cost())
+		if total.Cmp(balance) > 0 {
+			continue
+		}
+		if len(queue) == 0 && tx.Nonce == nonce+1 && pending < pool.Config.AccountSlots && pending < slots {
+			pool.appendPendingTx(from, tx)
+			pending++
+			nonce++
+			cost = total
+			continue
+		}
+		if uint64(len(queue)) < pool.Config.AccountQueue && uint64(len(queue)) < queueSlots {
+			pool.track(from, tx)
+			queue = append(queue, tx)
+		}
+	}
+	if len(queue) > 0 {
+		pool.queue[from] = queue
+	}
+	return pending, uint64(len(queue))
+}
+
+// drain 清空交易池，返回其中的全部交易（可执行交易与排队交易）。
+// 排队交易的时间记录保留，由 Reset 恢复给仍有排队交易的账户
+func (pool *DefaultPool) drain() []*common.Transaction {
+	txs := make([]*common.Transaction, 0, len(pool.all))
+	for _, tx := range pool.all {
+		txs = append(txs, tx)
+	}
+	pool.all = make(map[common.Hash]*common.Transaction)
+	pool.senders = make(map[*common.Transaction]common.Address)
+	pool.txs = nil
+	pool.pendings = make(map[common.Address]pendingTxs)
+	pool.queue = make(map[common.Address]QueueSortedTxs)
+	return txs
+}
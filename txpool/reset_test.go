@@ -0,0 +1,326 @@
+package txpool
+
+import (
+	"CHAIN/BlockChain"
+	"CHAIN/common"
+	"CHAIN/kvstore"
+	"CHAIN/params"
+	"CHAIN/statedb"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// testChain 按哈希保存所有已知区块，包括被重组丢弃的分叉
+type testChain map[string]*BlockChain.Block
+
+func (c testChain) GetBlock(hash []byte) *BlockChain.Block {
+	return c[string(hash)]
+}
+
+// block 以当前状态提交后的状态根创建 parent 的子区块
+func (c testChain) block(t *testing.T, stateDB *statedb.MPTStateDB, parent *BlockChain.Block, txs ...*common.Transaction) *BlockChain.Block {
+	root, err := stateDB.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := &BlockChain.Block{StateRoot: root, Transactions: txs}
+	if parent != nil {
+		block.Index = parent.Index + 1
+		block.PrevHash = parent.Hash
+	}
+	block.Hash = block.CalculateHash()
+	c[string(block.Hash)] = block
+	return block
+}
+
+func TestPoolResetOnNewHead(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	chain := testChain{}
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	pool.Chain = chain
+	keyA, addrA := newFundedKey(stateDB)
+	keyB, addrB := newFundedKey(stateDB)
+	keyC, addrC := newFundedKey(stateDB)
+	genesis := chain.block(t, stateDB, nil)
+
+	txsA := []*common.Transaction{generateTx(1, 10, keyA), generateTx(2, 10, keyA), generateTx(3, 10, keyA), generateTx(5, 10, keyA)}
+	txsB := []*common.Transaction{generateTx(1, 10, keyB), generateTx(2, 10, keyB)}
+	for _, tx := range append(txsA, txsB...) {
+		if err := pool.NewTx(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pool.NewTx(generateTx(3, 10, keyC))
+
+	// 新区块打包 A 的前两笔交易；C 的 nonce 1、2 在别处执行；B 的余额只够一笔
+	stateDB.SetNonce(addrA, 2)
+	stateDB.SetNonce(addrC, 2)
+	stateDB.Store(addrB, &common.Account{Balance: big.NewInt(400000)})
+	head := chain.block(t, stateDB, genesis, txsA[0], txsA[1])
+	if err := pool.Reset(genesis, head); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+
+	if pool.Has(txHash(txsA[0])) || pool.Has(txHash(txsA[1])) {
+		t.Fatal("included transactions still pooled")
+	}
+	if pool.pendingLen(addrA) != 1 || pool.pendings[addrA][0].Nonce() != 3 {
+		t.Fatalf("A pending = %d, want only nonce 3", pool.pendingLen(addrA))
+	}
+	if len(pool.queue[addrA]) != 1 || pool.queue[addrA][0].Nonce != 5 {
+		t.Fatalf("A queue = %v, want nonce 5", pool.queue[addrA])
+	}
+	// B 的第二笔交易超出余额被丢弃
+	if pool.pendingLen(addrB) != 1 || pool.Has(txHash(txsB[1])) {
+		t.Fatalf("B pending = %d, want 1", pool.pendingLen(addrB))
+	}
+	// C 的排队交易变得可执行
+	if pool.pendingLen(addrC) != 1 || len(pool.queue[addrC]) != 0 {
+		t.Fatalf("C pending = %d queue = %d, want promoted", pool.pendingLen(addrC), len(pool.queue[addrC]))
+	}
+	if len(pool.all) != 4 {
+		t.Fatalf("all = %d, want 4", len(pool.all))
+	}
+}
+
+func TestPoolResetChildWithoutChain(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	chain := testChain{}
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	key, addr := newFundedKey(stateDB)
+	genesis := chain.block(t, stateDB, nil)
+
+	// 价格高于重置后的基础费用，才能被弹出
+	price := uint64(2 * params.InitialBaseFee)
+	tx1, tx2 := generateTx(1, price, key), generateTx(2, price, key)
+	pool.NewTx(tx1)
+	pool.NewTx(tx2)
+	stateDB.SetNonce(addr, 1)
+	head := chain.block(t, stateDB, genesis, tx1)
+
+	// 导入子区块不依赖 ChainReader
+	if pool.Chain != nil {
+		t.Fatal("pool has a chain reader by default")
+	}
+	if err := pool.Reset(genesis, head); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if pool.Has(txHash(tx1)) || pool.Pop() != tx2 {
+		t.Fatal("included transaction not removed")
+	}
+}
+
+func TestPoolResetDemotesGappedPending(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	chain := testChain{}
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	pool.Chain = chain
+	key, addr := newFundedKey(stateDB)
+	genesis := chain.block(t, stateDB, nil)
+
+	// 第一笔交易的价格超过新余额可支付的范围，之后的交易失去连续性
+	pool.NewTx(generateTx(1, 20, key))
+	pool.NewTx(generateTx(2, 1, key))
+	pool.NewTx(generateTx(3, 1, key))
+	stateDB.Store(addr, &common.Account{Balance: big.NewInt(500000)})
+	head := chain.block(t, stateDB, genesis)
+	if err := pool.Reset(genesis, head); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if pool.pendingLen(addr) != 0 || len(pool.queue[addr]) != 2 {
+		t.Fatalf("pending = %d queue = %d, want nonces 2 and 3 queued", pool.pendingLen(addr), len(pool.queue[addr]))
+	}
+}
+
+func TestPoolResetReorg(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	chain := testChain{}
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	pool.Chain = chain
+	key, addr := newFundedKey(stateDB)
+	genesis := chain.block(t, stateDB, nil)
+
+	price := uint64(2 * params.InitialBaseFee)
+	tx1, tx2, tx3 := generateTx(1, price, key), generateTx(2, price, key), generateTx(3, price, key)
+	pool.NewTx(tx3)
+
+	// 旧链打包 tx1、tx2，新分叉更长但只打包 tx1
+	stateDB.SetNonce(addr, 2)
+	oldHead := chain.block(t, stateDB, genesis, tx1, tx2)
+	if err := stateDB.SetRoot(genesis.StateRoot); err != nil {
+		t.Fatal(err)
+	}
+	stateDB.SetNonce(addr, 1)
+	fork := chain.block(t, stateDB, genesis, tx1)
+	newHead := chain.block(t, stateDB, fork)
+
+	if err := pool.Reset(oldHead, newHead); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if pool.Has(txHash(tx1)) {
+		t.Fatal("transaction included in the new chain reinjected")
+	}
+	// 被丢弃的 tx2 重新加入，tx3 随之变得可执行
+	if pool.pendingLen(addr) != 2 || pool.Pop() != tx2 || pool.Pop() != tx3 {
+		t.Fatal("dropped transaction not reinjected before tx3")
+	}
+}
+
+func TestPoolResetReorgOnChain(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	var addr common.Address
+	copy(addr[:], crypto.PubkeyToAddress(key.PublicKey).Bytes())
+	db := kvstore.NewMemoryKVStore()
+	g := &BlockChain.Genesis{
+		Config:   params.DefaultChainConfig,
+		GasLimit: 8000000,
+		Alloc:    map[common.Address]BlockChain.GenesisAccount{addr: {Balance: big.NewInt(1e18)}},
+	}
+	genesis, err := BlockChain.SetupGenesisBlock(db, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := BlockChain.NewChain(db, g.Config, genesis)
+	stateDB, err := statedb.New(genesis.StateRoot, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	pool.Chain = chain
+
+	// insert 在 parent 的状态上执行 txs，生成子区块并导入链
+	insert := func(parent *BlockChain.Block, coinbase common.Address, txs ...*common.Transaction) *BlockChain.Block {
+		t.Helper()
+		state, err := statedb.New(parent.StateRoot, db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block := BlockChain.NewBlock(nil, parent.Hash, parent.Index+1)
+		block.GasLimit, block.Coinbase = parent.GasLimit, coinbase
+		block.BaseFee = BlockChain.CalcBaseFee(g.Config, parent)
+		signer := common.MakeSigner(g.Config, block.Index)
+		for _, tx := range txs {
+			gas, err := BlockChain.ApplyTransaction(state, signer, block, tx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			block.GasUsed += gas
+		}
+		if block.StateRoot, err = state.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		block.Transactions = txs
+		block.Hash = block.CalculateHash()
+		if err := chain.InsertBlock(block); err != nil {
+			t.Fatalf("InsertBlock failed: %v", err)
+		}
+		return block
+	}
+
+	price := uint64(2 * params.InitialBaseFee)
+	tx1, tx2 := generateTx(1, price, key), generateTx(2, price, key)
+	oldHead := insert(genesis, common.Address{1}, tx1, tx2)
+
+	// 另一分支只打包 tx1，变得更长后成为规范链
+	fork := insert(genesis, common.Address{2}, tx1)
+	newHead := insert(fork, common.Address{2})
+	if chain.CurrentBlock() != newHead {
+		t.Fatal("longer fork did not become the head")
+	}
+
+	if err := pool.Reset(oldHead, newHead); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	// 被丢弃区块中未被新链打包的 tx2 重新加入
+	if pool.Has(txHash(tx1)) || pool.pendingLen(addr) != 1 || pool.Pop() != tx2 {
+		t.Fatal("dropped transaction not reinjected")
+	}
+}
+
+func TestPoolResetUnknownAncestor(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	chain := testChain{}
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	pool.Chain = chain
+	key, addr := newFundedKey(stateDB)
+	pool.NewTx(generateTx(1, 10, key))
+
+	stateDB.SetNonce(addr, 1)
+	orphan := &BlockChain.Block{Index: 5, PrevHash: []byte("missing"), Hash: []byte("orphan")}
+	head := chain.block(t, stateDB, orphan)
+	if err := pool.Reset(&BlockChain.Block{Index: 3}, head); !errors.Is(err, ErrUnknownAncestor) {
+		t.Fatalf("Reset = %v, want ErrUnknownAncestor", err)
+	}
+	// 仍按新状态重新校验
+	if len(pool.all) != 0 {
+		t.Fatalf("all = %d, want 0", len(pool.all))
+	}
+}
+
+func TestPoolResetBaseFee(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	chain := testChain{}
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	genesis := chain.block(t, stateDB, nil)
+
+	// 父区块 Gas 用满，下一个区块的基础费用上调 1/8
+	head := chain.block(t, stateDB, genesis)
+	head.GasLimit, head.GasUsed = 8000000, 8000000
+	head.BaseFee = big.NewInt(8000)
+	if err := pool.Reset(genesis, head); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if pool.baseFee == nil || pool.baseFee.Cmp(big.NewInt(9000)) != 0 {
+		t.Fatalf("base fee = %v, want 9000", pool.baseFee)
+	}
+	if pool.gasLimit != head.GasLimit {
+		t.Fatalf("gas limit = %d, want %d", pool.gasLimit, head.GasLimit)
+	}
+}
+
+func TestPoolResetRespectsLimits(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	chain := testChain{}
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	pool.Chain = chain
+	pool.Config.AccountSlots = 2
+	pool.Config.AccountQueue = 1
+	key, addr := newFundedKey(stateDB)
+	genesis := chain.block(t, stateDB, nil)
+
+	// 旧链打包 nonce 1~3，重组到空分叉后三笔交易重新加入，池中原有的 nonce 4 排在其后
+	txs := []*common.Transaction{generateTx(1, 10, key), generateTx(2, 10, key), generateTx(3, 10, key), generateTx(4, 10, key)}
+	stateDB.SetNonce(addr, 3)
+	oldHead := chain.block(t, stateDB, genesis, txs[:3]...)
+	pool.Reset(genesis, oldHead)
+	if err := pool.NewTx(txs[3]); err != nil {
+		t.Fatal(err)
+	}
+	if err := stateDB.SetRoot(genesis.StateRoot); err != nil {
+		t.Fatal(err)
+	}
+	stateDB.SetNonce(addr, 0)
+	fork := chain.block(t, stateDB, genesis)
+	newHead := chain.block(t, stateDB, fork)
+	if err := pool.Reset(oldHead, newHead); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+
+	if pool.pendingLen(addr) != 2 || len(pool.queue[addr]) != 1 || pool.queue[addr][0] != txs[2] {
+		t.Fatalf("pending = %d queue = %v, want nonces 1-2 pending and 3 queued", pool.pendingLen(addr), pool.queue[addr])
+	}
+	if pool.Has(txHash(txs[3])) || len(pool.all) != 3 || len(pool.senders) != 3 {
+		t.Fatalf("all = %d senders = %d, want 3", len(pool.all), len(pool.senders))
+	}
+}
@@ -1,13 +1,16 @@
 package txpool
 
 import (
-	"github.com/ethereum/go-ethereum/core/types"
-	"hash"
+	"CHAIN/BlockChain"
+	"CHAIN/common"
 )
 
 type TxPool interface {
-	NewTx(tx *types.Transaction)            //接收一个 `*types.Transaction` 类型的参数 `tx`，用于将新的交易加入到交易池中。
-	PoP() *types.Transaction                //返回一个 `*types.Transaction` 类型的指针，可能是从交易池中弹出的交易。
-	SetStatRoot(root hash.Hash)             //接收一个 `hash.Hash` 类型的参数 `root`，用于设置状态根。
-	NotifyTxEvent(txs []*types.Transaction) //接收一个 `[]*types.Transaction` 类型的切片 `txs`，用于通知交易事件。
+	NewTx(tx *common.Transaction) error             //接收一个 `*common.Transaction` 类型的参数 `tx`，用于将新的交易加入到交易池中，返回拒绝的原因。
+	AddTxs(txs []*common.Transaction) []error       //批量加入交易，返回与 `txs` 一一对应的结果。
+	Pop() *common.Transaction                       //返回一个 `*common.Transaction` 类型的指针，可能是从交易池中弹出的交易。
+	Reset(oldHead, newHead *BlockChain.Block) error //接收旧链头和新链头，按新链头的状态重新整理交易池。
+	NotifyTxEvent(txs []*common.Transaction)        //接收一个 `[]*common.Transaction` 类型的切片 `txs`，用于通知交易事件。
 }
+
+var _ TxPool = (*DefaultPool)(nil)
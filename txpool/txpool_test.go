@@ -3,10 +3,14 @@ package txpool
 
 import (
 	"CHAIN/common"
+	"CHAIN/params"
 	"CHAIN/statedb"
+	"bytes"
 	"crypto/ecdsa"
+	"errors"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
 )
@@ -15,36 +19,32 @@ func generateTx(nonce uint64, gasPrice uint64, priv *ecdsa.PrivateKey) *common.T
 	to := common.Address{9, 9, 9}
 	msg := []byte("dummy")
 	tx := &common.Transaction{
-		Fro:       common.Address{}, // 会由 From() 动态生成
-		To:        &to,
-		Nonce:     nonce,
-		GasLimit:  21000,
-		GasPrice:  big.NewInt(int64(gasPrice)),
-		Value:     big.NewInt(100),
-		Input:     msg,
-		Signature: []byte("placeholder"),
-	}
-
-	hash := tx.Hash()
-	sig, err := crypto.Sign(hash, priv)
-	if err != nil {
+		To:       &to,
+		Nonce:    nonce,
+		GasLimit: 30000,
+		GasPrice: big.NewInt(int64(gasPrice)),
+		Value:    big.NewInt(100),
+		Input:    msg,
+	}
+
+	if _, err := (common.HomesteadSigner{}).SignTx(tx, priv); err != nil {
 		panic(err)
 	}
-	tx.Signature = sig
-	tx.R = new(big.Int).SetBytes(sig[:32])
-	tx.S = new(big.Int).SetBytes(sig[32:64])
-	tx.V = uint8(sig[64]) + 27
-	tx.Fro = tx.From() // 补上真正 From 地址
 	return tx
 }
 
+// fundedAccount 返回余额足以支付测试交易的账户
+func fundedAccount() *common.Account {
+	return &common.Account{Balance: big.NewInt(1e18)}
+}
+
 func TestDefaultPool_Behaviors(t *testing.T) {
 	stateDB := statedb.NewInMemoryStateDB()
 	privKey, _ := crypto.GenerateKey()
 	addr := crypto.PubkeyToAddress(privKey.PublicKey)
 	var a common.Address
 	copy(a[:], addr[:])
-	stateDB.Store(a, &common.Account{Nonce: 0})
+	stateDB.Store(a, fundedAccount())
 
 	pool := NewDefaultPool(nil)
 	pool.State = stateDB
@@ -104,3 +104,761 @@ func TestDefaultPool_Behaviors(t *testing.T) {
 	})
 	t.Log("TestDefaultPool_Behaviors completed successfully")
 }
+
+func TestNewTxRejectsInvalidSignature(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	privKey, _ := crypto.GenerateKey()
+	tx := generateTx(1, 10, privKey)
+	from, _ := tx.From()
+	stateDB.Store(from, fundedAccount())
+
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+
+	// 垃圾签名不能让节点崩溃，也不能进入交易池
+	junk := generateTx(1, 10, privKey)
+	junk.V = big.NewInt(99)
+	if _, err := pool.validateTx(junk); !errors.Is(err, ErrInvalidSender) {
+		t.Fatalf("validateTx = %v, want ErrInvalidSender", err)
+	}
+	pool.NewTx(junk)
+	if len(pool.pendings) != 0 || len(pool.queue) != 0 {
+		t.Fatal("transaction with invalid signature was admitted")
+	}
+
+	// 其他链的交易同样被拒绝
+	foreign := &common.Transaction{To: tx.To, Nonce: 1, GasPrice: big.NewInt(10), Value: big.NewInt(1)}
+	common.NewEIP155Signer(big.NewInt(1)).SignTx(foreign, privKey)
+	pool.NewTx(foreign)
+	if len(pool.pendings) != 0 {
+		t.Fatal("transaction signed for another chain was admitted")
+	}
+
+	pool.NewTx(tx)
+	if len(pool.pendings[from]) != 1 {
+		t.Fatal("valid transaction was not admitted")
+	}
+}
+
+func TestPopOrdersByEffectiveTip(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	signer := common.NewLondonSigner(big.NewInt(1337))
+	pool.Signer = signer
+
+	newKey := func() *ecdsa.PrivateKey {
+		key, _ := crypto.GenerateKey()
+		var addr common.Address
+		copy(addr[:], crypto.PubkeyToAddress(key.PublicKey).Bytes())
+		stateDB.Store(addr, fundedAccount())
+		return key
+	}
+	to := common.Address{9}
+	dynamicTx := func(key *ecdsa.PrivateKey, nonce uint64, tipCap, feeCap int64) *common.Transaction {
+		tx := &common.Transaction{
+			Type: common.DynamicFeeTxType, Nonce: nonce, GasTipCap: big.NewInt(tipCap), GasFeeCap: big.NewInt(feeCap),
+			GasLimit: 21000, To: &to, Value: big.NewInt(1),
+		}
+		signer.SignTx(tx, key)
+		return tx
+	}
+	legacyTx := func(key *ecdsa.PrivateKey, nonce uint64, price int64) *common.Transaction {
+		tx := &common.Transaction{Nonce: nonce, GasPrice: big.NewInt(price), GasLimit: 21000, To: &to, Value: big.NewInt(1)}
+		signer.SignTx(tx, key)
+		return tx
+	}
+
+	keyA, keyB, keyC := newKey(), newKey(), newKey()
+	a1 := legacyTx(keyA, 1, 50)        // 小费 = 50 - baseFee
+	b1 := dynamicTx(keyB, 1, 35, 1000) // 小费 = min(35, 1000 - baseFee)
+	c1 := dynamicTx(keyC, 1, 40, 1000)
+	c2 := dynamicTx(keyC, 2, 5, 1000) // 同一发送者小费更低的后续交易
+	for _, tx := range []*common.Transaction{a1, b1, c1, c2} {
+		pool.NewTx(tx)
+	}
+
+	// 没有基础费用时 a1 小费最高
+	if got := pool.txs[0]; (*got)[0] != a1 {
+		t.Fatal("expected a1 first without base fee")
+	}
+
+	// 基础费用 30：a1 小费 20，b1 35，c1 40，c2 5
+	pool.SetBaseFee(big.NewInt(30))
+	want := []*common.Transaction{c1, b1, a1, c2}
+	for i, w := range want {
+		if got := pool.Pop(); got != w {
+			t.Fatalf("pop %d: got nonce %d tip %v, want nonce %d tip %v", i, got.Nonce, got.TipCap(), w.Nonce, w.TipCap())
+		}
+	}
+	if pool.Pop() != nil {
+		t.Fatal("pool should be empty")
+	}
+
+	// 同一发送者的后续交易不会先于前面的交易弹出
+	keyD := newKey()
+	pool.NewTx(dynamicTx(keyD, 1, 1, 1000))
+	pool.NewTx(dynamicTx(keyD, 2, 100, 1000))
+	if got := pool.Pop(); got == nil || got.Nonce != 1 {
+		t.Fatalf("expected nonce 1 first, got %+v", got)
+	}
+}
+
+func TestPopSkipsFeeCapBelowBaseFee(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	keyA, _ := newFundedKey(stateDB)
+	keyB, addrB := newFundedKey(stateDB)
+
+	cheap, rich := generateTx(1, 500, keyB), generateTx(1, 2000, keyA)
+	pool.NewTx(cheap)
+	pool.NewTx(rich)
+	pool.SetBaseFee(big.NewInt(1000))
+
+	// 最高价格低于基础费用的交易不被弹出，仍留在池中
+	if got := pool.Pop(); got != rich {
+		t.Fatalf("Pop = %+v, want the transaction above the base fee", got)
+	}
+	if got := pool.Pop(); got != nil {
+		t.Fatalf("Pop = %+v, want nil while the fee cap is below the base fee", got)
+	}
+	if !pool.Has(txHash(cheap)) || pool.pendingLen(addrB) != 1 {
+		t.Fatal("transaction below the base fee was dropped")
+	}
+
+	// 基础费用下降后可以弹出
+	pool.SetBaseFee(big.NewInt(100))
+	if got := pool.Pop(); got != cheap {
+		t.Fatalf("Pop = %+v, want the transaction after the base fee fell", got)
+	}
+}
+
+func TestNewTxRejectsUnsupportedType(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	key, _ := crypto.GenerateKey()
+	var from common.Address
+	copy(from[:], crypto.PubkeyToAddress(key.PublicKey).Bytes())
+	stateDB.Store(from, fundedAccount())
+
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	pool.Signer = common.NewEIP155Signer(big.NewInt(1337)) // 分叉前只接受 legacy 交易
+
+	to := common.Address{9}
+	tx := &common.Transaction{Type: common.AccessListTxType, Nonce: 1, GasPrice: big.NewInt(1), GasLimit: 30000, To: &to}
+	common.NewBerlinSigner(big.NewInt(1337)).SignTx(tx, key)
+	if _, err := pool.validateTx(tx); !errors.Is(err, ErrInvalidSender) || !errors.Is(err, common.ErrTxTypeNotSupported) {
+		t.Fatalf("validateTx = %v, want ErrTxTypeNotSupported", err)
+	}
+	pool.NewTx(tx)
+	if len(pool.pendings) != 0 {
+		t.Fatal("unsupported transaction type was admitted")
+	}
+
+	pool.Signer = common.NewBerlinSigner(big.NewInt(1337))
+	pool.NewTx(tx)
+	if len(pool.pendings[from]) != 1 {
+		t.Fatal("access list transaction was not admitted")
+	}
+}
+
+func TestNewTxRejectsNegativeValues(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	signer := common.NewLondonSigner(big.NewInt(1337))
+	pool.Signer = signer
+	key, _ := newFundedKey(stateDB)
+
+	to := common.Address{9}
+	fields := map[string]func(tx *common.Transaction){
+		"value":       func(tx *common.Transaction) { tx.Value = big.NewInt(-1) },
+		"gas price":   func(tx *common.Transaction) { tx.GasPrice = big.NewInt(-1) },
+		"gas fee cap": func(tx *common.Transaction) { tx.GasFeeCap = big.NewInt(-1) },
+		"gas tip cap": func(tx *common.Transaction) { tx.GasTipCap = big.NewInt(-1) },
+	}
+	for name, set := range fields {
+		tx := &common.Transaction{Nonce: 1, GasPrice: big.NewInt(10), GasLimit: 30000, To: &to, Value: big.NewInt(1)}
+		if name == "gas fee cap" || name == "gas tip cap" {
+			tx.Type, tx.GasPrice = common.DynamicFeeTxType, nil
+			tx.GasTipCap, tx.GasFeeCap = big.NewInt(1), big.NewInt(10)
+		}
+		if _, err := signer.SignTx(tx, key); err != nil {
+			t.Fatal(err)
+		}
+		// 签名后篡改为负数：交易池返回错误而不是 panic
+		set(tx)
+		if err := pool.NewTx(tx); !errors.Is(err, ErrNegativeValue) {
+			t.Fatalf("%s: NewTx = %v, want ErrNegativeValue", name, err)
+		}
+	}
+	if len(pool.all) != 0 {
+		t.Fatal("transaction with negative value was admitted")
+	}
+}
+
+func TestNewTxRejectsContractCreation(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	key, _ := newFundedKey(stateDB)
+
+	tx := &common.Transaction{Nonce: 1, GasPrice: big.NewInt(10), GasLimit: 60000, Input: []byte{0x60, 0x00}}
+	if _, err := pool.Signer.SignTx(tx, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.NewTx(tx); !errors.Is(err, ErrContractCreation) {
+		t.Fatalf("NewTx(contract creation) = %v, want ErrContractCreation", err)
+	}
+	if len(pool.all) != 0 {
+		t.Fatal("contract creation was admitted")
+	}
+}
+
+// newFundedKey 生成私钥并在状态中创建对应账户
+func newFundedKey(stateDB *statedb.MPTStateDB) (*ecdsa.PrivateKey, common.Address) {
+	key, _ := crypto.GenerateKey()
+	var addr common.Address
+	copy(addr[:], crypto.PubkeyToAddress(key.PublicKey).Bytes())
+	stateDB.Store(addr, fundedAccount())
+	return key, addr
+}
+
+func TestPoolAccountLimits(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	pool.Config.AccountSlots = 2
+	pool.Config.AccountQueue = 1
+	key, addr := newFundedKey(stateDB)
+
+	pool.NewTx(generateTx(1, 10, key))
+	pool.NewTx(generateTx(2, 10, key))
+	if err := pool.NewTx(generateTx(3, 10, key)); !errors.Is(err, ErrAccountLimitExceeded) {
+		t.Fatalf("NewTx over account slots = %v, want ErrAccountLimitExceeded", err)
+	}
+	if got := pool.pendingLen(addr); got != 2 {
+		t.Fatalf("pending = %d, want 2", got)
+	}
+	if len(pool.queue[addr]) != 0 {
+		t.Fatal("transaction over the account pending limit should be dropped")
+	}
+
+	pool.NewTx(generateTx(5, 10, key))
+	if err := pool.NewTx(generateTx(6, 10, key)); !errors.Is(err, ErrAccountLimitExceeded) {
+		t.Fatalf("NewTx over account queue = %v, want ErrAccountLimitExceeded", err)
+	}
+	if len(pool.queue[addr]) != 1 || pool.queue[addr][0].Nonce != 5 {
+		t.Fatalf("queue = %v, want only nonce 5", pool.queue[addr])
+	}
+	if len(pool.all) != 3 {
+		t.Fatalf("all = %d, want 3", len(pool.all))
+	}
+}
+
+func TestPoolGlobalPendingEviction(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	pool.Config.GlobalSlots = 2
+
+	keyA, addrA := newFundedKey(stateDB)
+	keyB, addrB := newFundedKey(stateDB)
+	keyC, addrC := newFundedKey(stateDB)
+	pool.NewTx(generateTx(1, 10, keyA))
+	pool.NewTx(generateTx(1, 20, keyB))
+
+	// 池满时比最便宜的交易更便宜的交易被拒绝
+	cheap := generateTx(1, 5, keyC)
+	if err := pool.NewTx(cheap); !errors.Is(err, ErrUnderpriced) {
+		t.Fatalf("NewTx(cheap) = %v, want ErrUnderpriced", err)
+	}
+	if pool.pendingLen(addrC) != 0 {
+		t.Fatal("underpriced transaction admitted into full pool")
+	}
+
+	// 更贵的交易驱逐最便宜的一笔
+	if err := pool.NewTx(generateTx(1, 30, keyC)); err != nil {
+		t.Fatalf("NewTx failed: %v", err)
+	}
+	if pool.pendingLen(addrA) != 0 || pool.pendingLen(addrB) != 1 || pool.pendingLen(addrC) != 1 {
+		t.Fatalf("pending after eviction: A=%d B=%d C=%d", pool.pendingLen(addrA), pool.pendingLen(addrB), pool.pendingLen(addrC))
+	}
+	if _, ok := pool.pendings[addrA]; ok {
+		t.Fatal("evicted account still has a pending entry")
+	}
+	if len(pool.all) != 2 || len(pool.txs) != 2 {
+		t.Fatalf("all = %d, groups = %d, want 2", len(pool.all), len(pool.txs))
+	}
+
+	// 只驱逐账户末尾的交易，不留下 nonce 空洞
+	pool.Config.GlobalSlots = 3
+	pool.NewTx(generateTx(2, 25, keyB))
+	pool.NewTx(generateTx(2, 40, keyC))
+	if pool.pendingLen(addrB) != 1 || pool.pendings[addrB][0].Nonce() != 1 {
+		t.Fatalf("expected B's nonce 2 to be evicted, got %d pending", pool.pendingLen(addrB))
+	}
+}
+
+func TestPoolEvictionTieBreak(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	pool.Config.GlobalSlots = 4
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	addrs := make([]common.Address, 3)
+	for i := range keys {
+		keys[i], addrs[i] = newFundedKey(stateDB)
+		pool.NewTx(generateTx(1, 10, keys[i]))
+	}
+	pool.NewTx(generateTx(2, 10, keys[0]))
+	keyD, _ := newFundedKey(stateDB)
+
+	// 小费相同时先驱逐 nonce 最大的交易
+	if err := pool.NewTx(generateTx(1, 20, keyD)); err != nil {
+		t.Fatalf("NewTx failed: %v", err)
+	}
+	if pool.pendingLen(addrs[0]) != 1 {
+		t.Fatalf("pending of the sender with the highest nonce = %d, want 1", pool.pendingLen(addrs[0]))
+	}
+
+	// nonce 也相同时驱逐发送者地址最大的交易
+	highest := addrs[0]
+	for _, addr := range addrs[1:] {
+		if bytes.Compare(addr[:], highest[:]) > 0 {
+			highest = addr
+		}
+	}
+	if err := pool.NewTx(generateTx(2, 20, keyD)); err != nil {
+		t.Fatalf("NewTx failed: %v", err)
+	}
+	for _, addr := range addrs {
+		want := 1
+		if addr == highest {
+			want = 0
+		}
+		if pool.pendingLen(addr) != want {
+			t.Fatalf("pending of %s = %d, want %d", addr, pool.pendingLen(addr), want)
+		}
+	}
+
+	// 排队交易使用相同的规则
+	pool.Config.GlobalQueue = 2
+	pool.NewTx(generateTx(5, 10, keys[1]))
+	pool.NewTx(generateTx(6, 10, keys[1]))
+	if err := pool.NewTx(generateTx(5, 20, keyD)); err != nil {
+		t.Fatalf("NewTx failed: %v", err)
+	}
+	if q := pool.queue[addrs[1]]; len(q) != 1 || q[0].Nonce != 5 {
+		t.Fatalf("queue after eviction = %v, want nonce 5", q)
+	}
+}
+
+func TestPoolGlobalQueueEviction(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	pool.Config.GlobalQueue = 2
+
+	keyA, addrA := newFundedKey(stateDB)
+	keyB, addrB := newFundedKey(stateDB)
+	pool.NewTx(generateTx(5, 10, keyA))
+	pool.NewTx(generateTx(6, 15, keyA))
+	if err := pool.NewTx(generateTx(5, 8, keyB)); !errors.Is(err, ErrUnderpriced) {
+		t.Fatalf("NewTx(cheap) = %v, want ErrUnderpriced", err)
+	}
+	if len(pool.queue[addrB]) != 0 {
+		t.Fatal("underpriced transaction admitted into full queue")
+	}
+	pool.NewTx(generateTx(5, 20, keyB))
+	if len(pool.queue[addrA]) != 1 || pool.queue[addrA][0].Nonce != 6 || len(pool.queue[addrB]) != 1 {
+		t.Fatalf("queue after eviction: A=%v B=%v", pool.queue[addrA], pool.queue[addrB])
+	}
+	if pool.queueLen() != 2 || len(pool.all) != 2 {
+		t.Fatalf("queue = %d, all = %d, want 2", pool.queueLen(), len(pool.all))
+	}
+}
+
+func TestPoolQueueLifetime(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	now := time.Unix(1700000000, 0)
+	pool.clock = func() time.Time { return now }
+	pool.Config.Lifetime = time.Hour
+
+	keyA, addrA := newFundedKey(stateDB)
+	keyB, addrB := newFundedKey(stateDB)
+	pool.NewTx(generateTx(3, 10, keyA))
+	now = now.Add(30 * time.Minute)
+	pool.NewTx(generateTx(3, 10, keyB))
+
+	// A 排队超过一小时被丢弃，B 仍在有效期内
+	now = now.Add(45 * time.Minute)
+	if pool.Pop() != nil {
+		t.Fatal("unexpected executable transaction")
+	}
+	if _, ok := pool.queue[addrA]; ok {
+		t.Fatal("stale queue of A was not dropped")
+	}
+	if len(pool.queue[addrB]) != 1 || len(pool.all) != 1 {
+		t.Fatalf("queue of B = %v, all = %d", pool.queue[addrB], len(pool.all))
+	}
+
+	// 账户有新的排队交易时刷新时间，之后的交易补齐 nonce 空洞后排队交易被提升
+	pool.NewTx(generateTx(1, 10, keyB))
+	pool.NewTx(generateTx(4, 10, keyB))
+	now = now.Add(50 * time.Minute)
+	pool.NewTx(generateTx(2, 10, keyB))
+	if pool.pendingLen(addrB) != 4 {
+		t.Fatalf("pending of B = %d, want 4", pool.pendingLen(addrB))
+	}
+	for pool.Pop() != nil {
+	}
+	if len(pool.all) != 0 {
+		t.Fatalf("all = %d after draining pool, want 0", len(pool.all))
+	}
+}
+
+func TestReplaceByFee(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	key, addr := newFundedKey(stateDB)
+
+	orig := generateTx(1, 100, key)
+	pool.NewTx(orig)
+
+	// 价格相同、更低或提高不足 10% 的替换被拒绝
+	for _, price := range []uint64{100, 50, 109} {
+		replacement := generateTx(1, price, key)
+		if err := pool.replacePendingTx(addr, replacement); !errors.Is(err, ErrReplaceUnderpriced) {
+			t.Fatalf("replace with price %d = %v, want ErrReplaceUnderpriced", price, err)
+		}
+		pool.NewTx(replacement)
+	}
+	if got := (*pool.pendings[addr][0])[0]; got != orig {
+		t.Fatalf("underpriced replacement took effect: price %d", got.GasPriceUint64())
+	}
+
+	bumped := generateTx(1, 110, key)
+	pool.NewTx(bumped)
+	if got := (*pool.pendings[addr][0])[0]; got != bumped {
+		t.Fatal("replacement with 10% bump was not accepted")
+	}
+	if _, ok := pool.all[txHash(orig)]; ok || len(pool.all) != 1 {
+		t.Fatal("replaced transaction still indexed")
+	}
+
+	// 排队交易使用相同的规则
+	queued := generateTx(3, 100, key)
+	pool.NewTx(queued)
+	if err := pool.addQueueTx(addr, generateTx(3, 105, key)); !errors.Is(err, ErrReplaceUnderpriced) {
+		t.Fatalf("queue replacement = %v, want ErrReplaceUnderpriced", err)
+	}
+	pool.NewTx(generateTx(3, 200, key))
+	if len(pool.queue[addr]) != 1 || pool.queue[addr][0].GasPriceUint64() != 200 {
+		t.Fatalf("queue after replacement = %v", pool.queue[addr])
+	}
+
+	// 动态费用交易的小费也必须提高
+	pool.Config.PriceBump = 0
+	signer := common.NewLondonSigner(params.DefaultChainConfig.ChainID)
+	pool.Signer = signer
+	to := common.Address{9}
+	dynamic := func(tip, feeCap int64) *common.Transaction {
+		tx := &common.Transaction{Type: common.DynamicFeeTxType, Nonce: 1, GasTipCap: big.NewInt(tip), GasFeeCap: big.NewInt(feeCap), GasLimit: 21000, To: &to}
+		signer.SignTx(tx, key)
+		return tx
+	}
+	if err := pool.replacePendingTx(addr, dynamic(1, 1000)); !errors.Is(err, ErrReplaceUnderpriced) {
+		t.Fatalf("replacement with lower tip = %v, want ErrReplaceUnderpriced", err)
+	}
+	if err := pool.replacePendingTx(addr, dynamic(111, 1000)); err != nil {
+		t.Fatalf("replacement with higher tip and fee cap failed: %v", err)
+	}
+}
+
+func TestNewTxRejectsPoppedNonce(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	key, addr := newFundedKey(stateDB)
+
+	pool.NewTx(generateTx(1, 10, key))
+	pool.NewTx(generateTx(2, 10, key))
+	if pool.Pop() == nil {
+		t.Fatal("nothing popped")
+	}
+
+	// 已弹出但尚未反映到状态中的 nonce 不能再次加入
+	resubmit := generateTx(1, 20, key)
+	if err := pool.NewTx(resubmit); !errors.Is(err, ErrNonceTooLow) {
+		t.Fatalf("NewTx(popped nonce) = %v, want ErrNonceTooLow", err)
+	}
+	if pool.Has(txHash(resubmit)) || pool.pendingLen(addr) != 1 {
+		t.Fatalf("popped nonce resubmitted: pending = %d", pool.pendingLen(addr))
+	}
+
+	// 发送者的可执行交易全部弹出后，仍按已弹出的 nonce 而不是状态 nonce 判断
+	if pool.Pop() == nil || pool.pendingLen(addr) != 0 {
+		t.Fatal("second transaction not popped")
+	}
+	for nonce := uint64(1); nonce <= 2; nonce++ {
+		if err := pool.NewTx(generateTx(nonce, 30, key)); !errors.Is(err, ErrNonceTooLow) {
+			t.Fatalf("NewTx(popped nonce %d) = %v, want ErrNonceTooLow", nonce, err)
+		}
+	}
+	if err := pool.NewTx(generateTx(4, 10, key)); err != nil || len(pool.queue[addr]) != 1 {
+		t.Fatalf("NewTx(nonce 4) = %v, queue = %d, want queued", err, len(pool.queue[addr]))
+	}
+	if err := pool.NewTx(generateTx(3, 10, key)); err != nil || pool.pendingLen(addr) != 2 {
+		t.Fatalf("NewTx(nonce 3) = %v, pending = %d, want 3 and 4 pending", err, pool.pendingLen(addr))
+	}
+
+	// Reset 后以新状态为准，未被打包的已弹出 nonce 可以重新加入
+	if err := pool.Reset(nil, testChain{}.block(t, stateDB, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.NewTx(generateTx(1, 10, key)); err != nil {
+		t.Fatalf("NewTx(nonce 1) after Reset = %v", err)
+	}
+}
+
+func TestReplaceResortsPending(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	keyA, _ := newFundedKey(stateDB)
+	keyB, _ := newFundedKey(stateDB)
+
+	a1, a2 := generateTx(1, 10, keyA), generateTx(2, 5, keyA)
+	b1 := generateTx(1, 8, keyB)
+	for _, tx := range []*common.Transaction{a1, a2, b1} {
+		pool.NewTx(tx)
+	}
+
+	// a2 提价后与 a1 合为一组，整体排在 b1 之前
+	a2bumped := generateTx(2, 50, keyA)
+	pool.NewTx(a2bumped)
+	for i, want := range []*common.Transaction{a1, a2bumped, b1} {
+		if got := pool.Pop(); got != want {
+			t.Fatalf("pop %d: got nonce %d price %d", i, got.Nonce, got.GasPriceUint64())
+		}
+	}
+}
+
+func TestPoolLookupAndDuplicates(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	key, addr := newFundedKey(stateDB)
+
+	tx1 := generateTx(1, 10, key)
+	pool.NewTx(tx1)
+	hash := txHash(tx1)
+	if !pool.Has(hash) || pool.Get(hash) != tx1 {
+		t.Fatal("pooled transaction not found by hash")
+	}
+	if pool.Has(common.Hash{1}) || pool.Get(common.Hash{1}) != nil {
+		t.Fatal("unknown hash reported as pooled")
+	}
+
+	// 同一笔交易（包括解码得到的副本）不会重复加入
+	enc, _ := tx1.MarshalBinary()
+	var dup common.Transaction
+	if err := dup.UnmarshalBinary(enc); err != nil {
+		t.Fatal(err)
+	}
+	for _, tx := range []*common.Transaction{tx1, &dup} {
+		if err := pool.NewTx(tx); !errors.Is(err, ErrAlreadyKnown) {
+			t.Fatalf("NewTx(duplicate) = %v, want ErrAlreadyKnown", err)
+		}
+	}
+	if pool.pendingLen(addr) != 1 || len(pool.all) != 1 {
+		t.Fatalf("duplicate admitted: pending = %d, all = %d", pool.pendingLen(addr), len(pool.all))
+	}
+
+	if pool.Pop() != tx1 || pool.Has(hash) || len(pool.senders) != 0 {
+		t.Fatal("popped transaction still indexed")
+	}
+	if pool.Remove(hash) {
+		t.Fatal("Remove reported success for a transaction no longer pooled")
+	}
+}
+
+func TestPoolRemove(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	key, addr := newFundedKey(stateDB)
+
+	txs := make([]*common.Transaction, 4)
+	for i := range txs {
+		txs[i] = generateTx(uint64(i+1), 10, key)
+		pool.NewTx(txs[i])
+	}
+	queued := generateTx(6, 10, key)
+	pool.NewTx(queued)
+
+	if !pool.Remove(txHash(queued)) || len(pool.queue[addr]) != 0 {
+		t.Fatal("failed to remove queued transaction")
+	}
+
+	// 删除 nonce 2 后，nonce 3、4 移回排队队列
+	if !pool.Remove(txHash(txs[1])) {
+		t.Fatal("failed to remove pending transaction")
+	}
+	if pool.pendingLen(addr) != 1 || len(pool.queue[addr]) != 2 || pool.queue[addr][0] != txs[2] {
+		t.Fatalf("after remove: pending = %d, queue = %v", pool.pendingLen(addr), pool.queue[addr])
+	}
+	if len(pool.all) != 3 {
+		t.Fatalf("all = %d, want 3", len(pool.all))
+	}
+
+	// 重新提交 nonce 2 后排队交易再次被提升
+	pool.NewTx(txs[1])
+	if pool.pendingLen(addr) != 4 || len(pool.queue[addr]) != 0 {
+		t.Fatalf("after resubmit: pending = %d, queue = %d", pool.pendingLen(addr), len(pool.queue[addr]))
+	}
+	for i, want := range txs {
+		if got := pool.Pop(); got != want {
+			t.Fatalf("pop %d: got nonce %d", i, got.Nonce)
+		}
+	}
+}
+
+func TestPoolRemoveRespectsQueueLimits(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	pool.Config.AccountQueue = 1
+	key, addr := newFundedKey(stateDB)
+
+	txs := make([]*common.Transaction, 4)
+	for i := range txs {
+		txs[i] = generateTx(uint64(i+1), 10, key)
+		pool.NewTx(txs[i])
+	}
+
+	// 删除 nonce 2 后只有 nonce 3 能移回排队队列，nonce 4 被丢弃
+	if !pool.Remove(txHash(txs[1])) {
+		t.Fatal("failed to remove pending transaction")
+	}
+	if pool.pendingLen(addr) != 1 || len(pool.queue[addr]) != 1 || pool.queue[addr][0] != txs[2] {
+		t.Fatalf("after remove: pending = %d, queue = %v", pool.pendingLen(addr), pool.queue[addr])
+	}
+	if pool.Has(txHash(txs[3])) || len(pool.all) != 2 || len(pool.senders) != 2 {
+		t.Fatalf("all = %d, senders = %d, want 2", len(pool.all), len(pool.senders))
+	}
+}
+
+func TestValidateTxLimits(t *testing.T) {
+	pool := NewDefaultPool(nil)
+	pool.State = statedb.NewInMemoryStateDB()
+	pool.Config.MaxInputSize = 64
+	pool.SetGasLimit(100000)
+	key, _ := crypto.GenerateKey()
+	to := common.Address{9}
+
+	tests := []struct {
+		name string
+		tx   *common.Transaction
+		want error
+	}{
+		{"oversized", &common.Transaction{To: &to, GasLimit: 50000, Input: make([]byte, 65)}, ErrOversizedData},
+		{"over block gas limit", &common.Transaction{To: &to, GasLimit: 100001}, ErrGasLimit},
+		{"intrinsic gas", &common.Transaction{To: &to, GasLimit: 21000, Input: []byte{1}}, ErrIntrinsicGas},
+		{"access list gas", &common.Transaction{Type: common.AccessListTxType, To: &to, GasLimit: 21000,
+			AccessList: common.AccessList{{Address: to}}}, ErrIntrinsicGas},
+		{"contract creation", &common.Transaction{GasLimit: 50000}, ErrContractCreation},
+		{"negative value", &common.Transaction{To: &to, GasLimit: 21000, Value: big.NewInt(-1)}, ErrNegativeValue},
+		{"valid", &common.Transaction{To: &to, GasLimit: 21000 + 64*16, Input: bytes.Repeat([]byte{1}, 64)}, nil},
+	}
+	for _, test := range tests {
+		test.tx.GasPrice = big.NewInt(1)
+		common.NewLondonSigner(params.DefaultChainConfig.ChainID).SignTx(test.tx, key)
+		pool.Signer = common.NewLondonSigner(params.DefaultChainConfig.ChainID)
+		if _, err := pool.validateTx(test.tx); !errors.Is(err, test.want) || (test.want == nil && err != nil) {
+			t.Errorf("%s: validateTx = %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestValidateFunds(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	key, _ := crypto.GenerateKey()
+	var addr common.Address
+	copy(addr[:], crypto.PubkeyToAddress(key.PublicKey).Bytes())
+
+	// 每笔交易最多花费 30000*10 + 100 = 300100，余额只够两笔
+	stateDB.Store(addr, &common.Account{Balance: big.NewInt(650000)})
+	tx1, tx2, tx3 := generateTx(1, 10, key), generateTx(2, 10, key), generateTx(3, 10, key)
+	for _, tx := range []*common.Transaction{tx1, tx2, tx3} {
+		pool.NewTx(tx)
+	}
+	if pool.pendingLen(addr) != 2 {
+		t.Fatalf("pending = %d, want 2", pool.pendingLen(addr))
+	}
+	if err := pool.validateFunds(addr, tx3); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("validateFunds = %v, want ErrInsufficientFunds", err)
+	}
+
+	// 替换交易不与被替换的交易重复计算
+	if err := pool.validateFunds(addr, generateTx(2, 11, key)); err != nil {
+		t.Fatalf("replacement rejected: %v", err)
+	}
+	if err := pool.validateFunds(addr, generateTx(2, 20, key)); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("expensive replacement = %v, want ErrInsufficientFunds", err)
+	}
+
+	// 没有余额的账户不能提交交易
+	poorKey, poor := newFundedKey(stateDB)
+	stateDB.Store(poor, &common.Account{})
+	if err := pool.NewTx(generateTx(1, 1, poorKey)); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("NewTx = %v, want ErrInsufficientFunds", err)
+	}
+	if pool.pendingLen(poor) != 0 {
+		t.Fatal("transaction from account without balance admitted")
+	}
+}
+
+func TestAddTxsReportsReasons(t *testing.T) {
+	stateDB := statedb.NewInMemoryStateDB()
+	pool := NewDefaultPool(nil)
+	pool.State = stateDB
+	pool.Config.GlobalSlots = 2
+	key, addr := newFundedKey(stateDB)
+	stateDB.Store(addr, &common.Account{Balance: big.NewInt(1e18), Nonce: 1})
+	unknownKey, _ := crypto.GenerateKey()
+
+	tx2, tx3 := generateTx(2, 20, key), generateTx(3, 10, key)
+	unsigned := generateTx(5, 10, key)
+	unsigned.V, unsigned.R, unsigned.S = nil, nil, nil
+	errs := pool.AddTxs([]*common.Transaction{
+		tx2,
+		generateTx(1, 10, key),        // 已执行的 nonce
+		tx2,                           // 重复提交
+		generateTx(1, 10, unknownKey), // 状态中没有发送者
+		unsigned,                      // 无法恢复发送者
+		generateTx(2, 21, key),        // 价格提高不足的替换
+		tx3,
+		generateTx(4, 10, key), // 全局已满，只能驱逐自己的交易
+	})
+	want := []error{nil, ErrNonceTooLow, ErrAlreadyKnown, ErrUnknownSender, ErrInvalidSender, ErrReplaceUnderpriced, nil, ErrPoolFull}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d results, want %d", len(errs), len(want))
+	}
+	for i, err := range errs {
+		if !errors.Is(err, want[i]) {
+			t.Errorf("tx %d: err = %v, want %v", i, err, want[i])
+		}
+	}
+	if pool.pendingLen(addr) != 2 || len(pool.all) != 2 {
+		t.Fatalf("pending = %d, all = %d, want 2", pool.pendingLen(addr), len(pool.all))
+	}
+}